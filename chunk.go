@@ -0,0 +1,538 @@
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/advanderveer/go-dynamo/codec"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+const (
+	//maxItemSize is the size limit DynamoDB imposes on items
+	maxItemSize = 400 * 1024
+
+	//maxTransactItems is the number of items a transaction may hold
+	maxTransactItems = 100
+
+	//maxTransactSize is the size limit DynamoDB imposes on a transaction
+	maxTransactSize = 4 * 1024 * 1024
+
+	//DefaultChunkSize is the number of bytes each chunk item holds by default
+	DefaultChunkSize = 350 * 1024
+
+	//ChunksAttribute holds the number of chunks on the manifest of a chunked item
+	ChunksAttribute = "_chunks"
+
+	//ChunkDataAttribute holds the data of a chunk item
+	ChunkDataAttribute = "_chunk"
+
+	//maxChunkAttempts is the number of times a chunked item is read or written
+	//when the number of chunks changes concurrently
+	maxChunkAttempts = 3
+)
+
+//TransactClient is the part of the DynamoDB API that executes transactions,
+//it is satisfied by the dynamodbiface.DynamoDBAPI of the v1 SDK
+type TransactClient interface {
+	TransactGetItemsWithContext(aws.Context, *dynamodb.TransactGetItemsInput, ...request.Option) (*dynamodb.TransactGetItemsOutput, error)
+	TransactWriteItemsWithContext(aws.Context, *dynamodb.TransactWriteItemsInput, ...request.Option) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+//Chunking stores items that exceed the item size limit as a manifest item
+//plus chunk items that hold the item in the DynamoDB JSON format. The manifest
+//has the item's primary key and the number of chunks, chunk items have the same
+//primary key except that the value of the last key attribute (which must be a
+//string) is suffixed with "#chunk#" and the chunk's number. A put with chunking
+//first reads the number of chunks the item currently has, chunked items are
+//then written (and deleted) in a single transaction which limits them to a few
+//MB. The client must implement TransactClient. Projections are not applied to
+//chunked items and chunked items can't be read by queries and scans.
+//
+//Condition expressions of puts and deletes are evaluated against the manifest,
+//which only holds the key attributes and ChunksAttribute if the item is
+//chunked, conditions on other attributes can therefore only be used for items
+//that never exceed the size limit. The writes are additionally conditioned on
+//the number of chunks that was read, and retried when it changed concurrently.
+type Chunking struct {
+	KeyAttributes []string
+	ChunkSize     int
+}
+
+//NewChunking configures chunking for a table with the key attributes, the
+//hash key followed by the range key (if any)
+func NewChunking(keyAttributes ...string) *Chunking {
+	return &Chunking{KeyAttributes: keyAttributes, ChunkSize: DefaultChunkSize}
+}
+
+//SetChunkSize configures the number of bytes each chunk item holds
+func (ch *Chunking) SetChunkSize(n int) { ch.ChunkSize = n }
+
+//ChunkingInput is used when an execution can store large items in chunks
+type ChunkingInput struct {
+	Chunking *Chunking
+}
+
+//SetChunking enables storing items that exceed the item size limit in chunks,
+//the put, get and delete of such items must all be configured with it
+func (ci *ChunkingInput) SetChunking(ch *Chunking) { ci.Chunking = ch }
+
+//put writes the item directly or in chunks, stale chunks of the item are
+//deleted in both cases
+func (ch *Chunking) put(ctx aws.Context, db Client, in *dynamodb.PutItemInput) (out *dynamodb.PutItemOutput, err error) {
+	key, err := ch.key(in.Item)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks [][]byte
+	if itemSize(in.Item) > maxItemSize {
+		if chunks, err = ch.split(in.Item); err != nil {
+			return nil, err
+		}
+	}
+
+	err = ch.retry(ctx, db, in.TableName, key, func(old int) (err error) {
+		cond, names, values := guard(in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues, old)
+		if len(chunks) == 0 && old == 0 {
+			in := *in
+			in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues = cond, names, values
+			out, err = db.PutItemWithContext(ctx, &in)
+			return err
+		}
+
+		manifest := in.Item
+		if len(chunks) > 0 {
+			manifest = copyKey(key)
+			manifest[ChunksAttribute] = &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(len(chunks)))}
+		}
+
+		tx := []*dynamodb.TransactWriteItem{{Put: &dynamodb.Put{
+			TableName:                 in.TableName,
+			Item:                      manifest,
+			ConditionExpression:       cond,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}}}
+
+		for i, data := range chunks {
+			item, err := ch.chunkKey(key, i+1)
+			if err != nil {
+				return err
+			}
+
+			item[ChunkDataAttribute] = &dynamodb.AttributeValue{B: data}
+			tx = append(tx, &dynamodb.TransactWriteItem{Put: &dynamodb.Put{TableName: in.TableName, Item: item}})
+		}
+
+		if tx, err = ch.deleteChunks(tx, in.TableName, key, len(chunks)+1, old); err != nil {
+			return err
+		}
+
+		cc, err := ch.write(ctx, db, tx, in.ReturnConsumedCapacity)
+		if err != nil {
+			return err
+		}
+
+		out = &dynamodb.PutItemOutput{ConsumedCapacity: cc}
+		return nil
+	})
+
+	return out, err
+}
+
+//delete deletes the item directly or together with its chunks
+func (ch *Chunking) delete(ctx aws.Context, db Client, in *dynamodb.DeleteItemInput) (out *dynamodb.DeleteItemOutput, err error) {
+	err = ch.retry(ctx, db, in.TableName, in.Key, func(old int) (err error) {
+		cond, names, values := guard(in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues, old)
+		if old == 0 {
+			in := *in
+			in.ConditionExpression, in.ExpressionAttributeNames, in.ExpressionAttributeValues = cond, names, values
+			out, err = db.DeleteItemWithContext(ctx, &in)
+			return err
+		}
+
+		tx := []*dynamodb.TransactWriteItem{{Delete: &dynamodb.Delete{
+			TableName:                 in.TableName,
+			Key:                       in.Key,
+			ConditionExpression:       cond,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}}}
+
+		if tx, err = ch.deleteChunks(tx, in.TableName, in.Key, 1, old); err != nil {
+			return err
+		}
+
+		cc, err := ch.write(ctx, db, tx, in.ReturnConsumedCapacity)
+		if err != nil {
+			return err
+		}
+
+		out = &dynamodb.DeleteItemOutput{ConsumedCapacity: cc}
+		return nil
+	})
+
+	return out, err
+}
+
+//retry calls write with the number of chunks the item currently has, a failed
+//condition is retried if that number changed in the meantime
+func (ch *Chunking) retry(ctx aws.Context, db Client, table *string, key map[string]*dynamodb.AttributeValue, write func(old int) error) error {
+	old, err := ch.count(ctx, db, table, key)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = write(old)
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+			return err
+		}
+
+		n, cerr := ch.count(ctx, db, table, key)
+		if cerr != nil || n == old {
+			return err
+		}
+
+		if attempt >= maxChunkAttempts {
+			return fmt.Errorf("number of chunks kept changing while the item was written")
+		}
+
+		old = n
+	}
+}
+
+//guard adds a condition on the number of chunks that was read to the condition
+//of a manifest write, the names and values are copied when they are extended
+func guard(cond *string, names map[string]*string, values map[string]*dynamodb.AttributeValue, old int) (*string, map[string]*string, map[string]*dynamodb.AttributeValue) {
+	gnames := make(map[string]*string, len(names)+1)
+	for ph, name := range names {
+		gnames[ph] = name
+	}
+
+	gnames["#dynamoChunks"] = aws.String(ChunksAttribute)
+	expr := "attribute_not_exists(#dynamoChunks)"
+	if old > 0 {
+		gvalues := make(map[string]*dynamodb.AttributeValue, len(values)+1)
+		for ph, val := range values {
+			gvalues[ph] = val
+		}
+
+		gvalues[":dynamoChunks"] = &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(old))}
+		values, expr = gvalues, "#dynamoChunks = :dynamoChunks"
+	}
+
+	if cond != nil {
+		expr = "(" + aws.StringValue(cond) + ") AND " + expr
+	}
+
+	return aws.String(expr), gnames, values
+}
+
+//reassemble reads the chunks of a manifest and decodes the item they hold,
+//items that are not chunked are returned as is. The manifest is read again in
+//the same transaction as the chunks, such that they are consistent.
+func (ch *Chunking) reassemble(ctx aws.Context, db Client, table *string, manifest map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+	n, err := chunkCount(manifest)
+	if err != nil || n == 0 {
+		return manifest, err
+	}
+
+	tc, ok := db.(TransactClient)
+	if !ok {
+		return nil, fmt.Errorf("client doesn't implement transactions, which are required to read chunked items")
+	}
+
+	key, err := ch.key(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		in := &dynamodb.TransactGetItemsInput{TransactItems: []*dynamodb.TransactGetItem{{Get: &dynamodb.Get{TableName: table, Key: key}}}}
+		for i := 1; i <= n; i++ {
+			ckey, err := ch.chunkKey(key, i)
+			if err != nil {
+				return nil, err
+			}
+
+			in.TransactItems = append(in.TransactItems, &dynamodb.TransactGetItem{Get: &dynamodb.Get{TableName: table, Key: ckey}})
+		}
+
+		out, err := tc.TransactGetItemsWithContext(ctx, in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunks: %+v", err)
+		}
+
+		if len(out.Responses) != n+1 {
+			return nil, fmt.Errorf("read %d of %d chunks", len(out.Responses)-1, n)
+		}
+
+		//the item may have been replaced or deleted since the manifest was read
+		current := out.Responses[0].Item
+		cn, err := chunkCount(current)
+		if err != nil {
+			return nil, err
+		}
+
+		if cn == 0 {
+			return current, nil
+		}
+
+		if cn != n {
+			if attempt >= maxChunkAttempts {
+				return nil, fmt.Errorf("number of chunks kept changing while the item was read")
+			}
+
+			n = cn
+			continue
+		}
+
+		var data []byte
+		for i, resp := range out.Responses[1:] {
+			av := resp.Item[ChunkDataAttribute]
+			if av == nil || av.B == nil {
+				return nil, fmt.Errorf("chunk %d of %d is missing", i+1, n)
+			}
+
+			data = append(data, av.B...)
+		}
+
+		item, err := codec.Unmarshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode chunked item: %+v", err)
+		}
+
+		return item, nil
+	}
+}
+
+//count reads the number of chunks the item with key currently has
+func (ch *Chunking) count(ctx aws.Context, db Client, table *string, key map[string]*dynamodb.AttributeValue) (int, error) {
+	out, err := db.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:                table,
+		Key:                      key,
+		ConsistentRead:           aws.Bool(true),
+		ProjectionExpression:     aws.String("#c"),
+		ExpressionAttributeNames: map[string]*string{"#c": aws.String(ChunksAttribute)},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read chunk count: %+v", err)
+	}
+
+	return chunkCount(out.Item)
+}
+
+//split encodes the item and splits the data into chunks
+func (ch *Chunking) split(item map[string]*dynamodb.AttributeValue) (chunks [][]byte, err error) {
+	data, err := codec.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode item: %+v", err)
+	}
+
+	size := ch.ChunkSize
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+
+	if size > maxItemSize-1024 {
+		return nil, fmt.Errorf("chunk size %d leaves no room for the chunk's key", size)
+	}
+
+	if len(data) > maxTransactSize-64*1024 {
+		return nil, fmt.Errorf("item of %d bytes exceeds the size that can be written in a transaction", len(data))
+	}
+
+	for len(data) > 0 {
+		n := size
+		if n > len(data) {
+			n = len(data)
+		}
+
+		chunks, data = append(chunks, data[:n]), data[n:]
+	}
+
+	return chunks, nil
+}
+
+//deleteChunks adds deletes of the chunks from first to last to tx
+func (ch *Chunking) deleteChunks(tx []*dynamodb.TransactWriteItem, table *string, key map[string]*dynamodb.AttributeValue, first, last int) ([]*dynamodb.TransactWriteItem, error) {
+	for i := first; i <= last; i++ {
+		ckey, err := ch.chunkKey(key, i)
+		if err != nil {
+			return nil, err
+		}
+
+		tx = append(tx, &dynamodb.TransactWriteItem{Delete: &dynamodb.Delete{TableName: table, Key: ckey}})
+	}
+
+	if len(tx) > maxTransactItems {
+		return nil, fmt.Errorf("writing the item requires %d transaction items, at most %d are supported", len(tx), maxTransactItems)
+	}
+
+	return tx, nil
+}
+
+//write executes the transaction, a failed condition of the first item is
+//returned as if the item was written directly
+func (ch *Chunking) write(ctx aws.Context, db Client, tx []*dynamodb.TransactWriteItem, rcc *string) (*dynamodb.ConsumedCapacity, error) {
+	tc, ok := db.(TransactClient)
+	if !ok {
+		return nil, fmt.Errorf("client doesn't implement transactions, which are required to write chunked items")
+	}
+
+	out, err := tc.TransactWriteItemsWithContext(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems:          tx,
+		ReturnConsumedCapacity: rcc,
+	})
+	if err != nil {
+		if conditionFailedFirst(err) {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", err)
+		}
+
+		return nil, err
+	}
+
+	var cc *dynamodb.ConsumedCapacity
+	for _, c := range out.ConsumedCapacity {
+		if cc == nil {
+			cc = &dynamodb.ConsumedCapacity{TableName: c.TableName, CapacityUnits: aws.Float64(0)}
+		}
+
+		cc.CapacityUnits = aws.Float64(aws.Float64Value(cc.CapacityUnits) + aws.Float64Value(c.CapacityUnits))
+	}
+
+	return cc, nil
+}
+
+//key returns the primary key of an item
+func (ch *Chunking) key(item map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+	if len(ch.KeyAttributes) == 0 {
+		return nil, fmt.Errorf("chunking requires the key attributes of the table")
+	}
+
+	key := map[string]*dynamodb.AttributeValue{}
+	for _, name := range ch.KeyAttributes {
+		av, ok := item[name]
+		if !ok {
+			return nil, fmt.Errorf("item has no key attribute '%s'", name)
+		}
+
+		key[name] = av
+	}
+
+	return key, nil
+}
+
+//chunkKey returns the key of the i-th chunk (starting at 1) of the item
+func (ch *Chunking) chunkKey(item map[string]*dynamodb.AttributeValue, i int) (map[string]*dynamodb.AttributeValue, error) {
+	key, err := ch.key(item)
+	if err != nil {
+		return nil, err
+	}
+
+	last := ch.KeyAttributes[len(ch.KeyAttributes)-1]
+	if key[last].S == nil {
+		return nil, fmt.Errorf("key attribute '%s' must be a string to chunk items", last)
+	}
+
+	key[last] = &dynamodb.AttributeValue{S: aws.String(fmt.Sprintf("%s#chunk#%d", aws.StringValue(key[last].S), i))}
+	return key, nil
+}
+
+//chunkCount returns the number of chunks of an item, zero if it isn't chunked
+func chunkCount(item map[string]*dynamodb.AttributeValue) (int, error) {
+	av, ok := item[ChunksAttribute]
+	if !ok || av.N == nil {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(aws.StringValue(av.N))
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of chunks: %+v", err)
+	}
+
+	return n, nil
+}
+
+//conditionFailedFirst reports whether a transaction was canceled because the
+//condition of its first item failed
+func conditionFailedFirst(err error) bool {
+	var tce *dynamodb.TransactionCanceledException
+	if errors.As(err, &tce) {
+		return len(tce.CancellationReasons) > 0 &&
+			aws.StringValue(tce.CancellationReasons[0].Code) == dynamodb.BatchStatementErrorCodeEnumConditionalCheckFailed
+	}
+
+	//the reasons are also listed in the message, e.g. "... [ConditionalCheckFailed, None]"
+	aerr, ok := err.(awserr.Error)
+	if !ok || aerr.Code() != dynamodb.ErrCodeTransactionCanceledException {
+		return false
+	}
+
+	msg := aerr.Message()
+	i := strings.Index(msg, "[")
+	return i >= 0 && strings.HasPrefix(msg[i+1:], "ConditionalCheckFailed")
+}
+
+//copyKey copies the attributes of a key
+func copyKey(key map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	c := make(map[string]*dynamodb.AttributeValue, len(key))
+	for name, av := range key {
+		c[name] = av
+	}
+
+	return c
+}
+
+//itemSize returns the size of an item as DynamoDB calculates it
+func itemSize(item map[string]*dynamodb.AttributeValue) (n int) {
+	for name, av := range item {
+		n += len(name) + valueSize(av)
+	}
+
+	return n
+}
+
+//valueSize returns the size of an attribute value as DynamoDB calculates it
+func valueSize(av *dynamodb.AttributeValue) (n int) {
+	switch {
+	case av == nil:
+		return 0
+	case av.S != nil:
+		return len(*av.S)
+	case av.N != nil:
+		return len(strings.TrimLeft(*av.N, "-0"))/2 + 1
+	case av.B != nil:
+		return len(av.B)
+	case av.BOOL != nil, av.NULL != nil:
+		return 1
+	case av.SS != nil:
+		for _, s := range av.SS {
+			n += len(aws.StringValue(s))
+		}
+	case av.NS != nil:
+		for _, s := range av.NS {
+			n += len(strings.TrimLeft(aws.StringValue(s), "-0"))/2 + 1
+		}
+	case av.BS != nil:
+		for _, b := range av.BS {
+			n += len(b)
+		}
+	case av.M != nil:
+		n = 3 + itemSize(av.M) + len(av.M)
+	case av.L != nil:
+		n = 3 + len(av.L)
+		for _, el := range av.L {
+			n += valueSize(el)
+		}
+	}
+
+	return n
+}
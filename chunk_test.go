@@ -0,0 +1,232 @@
+package dynamo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//memTable is an in-memory table keyed by the 'Id' attribute that supports
+//transactions and the conditions that chunking uses. The race func is called
+//once before the next write or transaction to simulate a concurrent writer.
+type memTable struct {
+	fakeDB
+	items map[string]map[string]*dynamodb.AttributeValue
+	race  func()
+}
+
+func newMemTable() *memTable {
+	tbl := &memTable{items: map[string]map[string]*dynamodb.AttributeValue{}}
+	tbl.getItem = func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		return &dynamodb.GetItemOutput{Item: tbl.items[aws.StringValue(in.Key["Id"].S)]}, nil
+	}
+
+	tbl.putItem = func(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+		tbl.raced()
+		id := aws.StringValue(in.Item["Id"].S)
+		if !tbl.check(id, in.ConditionExpression, in.ExpressionAttributeValues) {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+		}
+
+		tbl.items[id] = in.Item
+		return &dynamodb.PutItemOutput{}, nil
+	}
+
+	tbl.deleteItem = func(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+		tbl.raced()
+		id := aws.StringValue(in.Key["Id"].S)
+		if !tbl.check(id, in.ConditionExpression, in.ExpressionAttributeValues) {
+			return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+		}
+
+		delete(tbl.items, id)
+		return &dynamodb.DeleteItemOutput{}, nil
+	}
+
+	return tbl
+}
+
+//raced calls the race func once
+func (tbl *memTable) raced() {
+	if race := tbl.race; race != nil {
+		tbl.race = nil
+		race()
+	}
+}
+
+//check evaluates the conditions that the tests and chunking use against the
+//stored item
+func (tbl *memTable) check(id string, cond *string, values map[string]*dynamodb.AttributeValue) bool {
+	cur, exists := tbl.items[id]
+	expr := aws.StringValue(cond)
+	if strings.Contains(expr, "attribute_not_exists(Id)") && exists {
+		return false
+	}
+
+	if strings.Contains(expr, "attribute_not_exists(#dynamoChunks)") && cur[ChunksAttribute] != nil {
+		return false
+	}
+
+	if v := values[":dynamoChunks"]; v != nil && (cur[ChunksAttribute] == nil || aws.StringValue(cur[ChunksAttribute].N) != aws.StringValue(v.N)) {
+		return false
+	}
+
+	return true
+}
+
+func (tbl *memTable) TransactGetItemsWithContext(ctx aws.Context, in *dynamodb.TransactGetItemsInput, opts ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+	tbl.raced()
+	out := &dynamodb.TransactGetItemsOutput{}
+	for _, it := range in.TransactItems {
+		out.Responses = append(out.Responses, &dynamodb.ItemResponse{Item: tbl.items[aws.StringValue(it.Get.Key["Id"].S)]})
+	}
+
+	return out, nil
+}
+
+func (tbl *memTable) TransactWriteItemsWithContext(ctx aws.Context, in *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	tbl.raced()
+	first, passed := in.TransactItems[0], true
+	if first.Put != nil {
+		passed = tbl.check(aws.StringValue(first.Put.Item["Id"].S), first.Put.ConditionExpression, first.Put.ExpressionAttributeValues)
+	} else if first.Delete != nil {
+		passed = tbl.check(aws.StringValue(first.Delete.Key["Id"].S), first.Delete.ConditionExpression, first.Delete.ExpressionAttributeValues)
+	}
+
+	if !passed {
+		return nil, &dynamodb.TransactionCanceledException{CancellationReasons: []*dynamodb.CancellationReason{
+			{Code: aws.String("ConditionalCheckFailed")}, {Code: aws.String("None")},
+		}}
+	}
+
+	for _, it := range in.TransactItems {
+		if it.Put != nil {
+			tbl.items[aws.StringValue(it.Put.Item["Id"].S)] = it.Put.Item
+		} else {
+			delete(tbl.items, aws.StringValue(it.Delete.Key["Id"].S))
+		}
+	}
+
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+type blob struct {
+	Id   string
+	Data []byte
+}
+
+func TestChunking(t *testing.T) {
+	tbl := newMemTable()
+	ch := NewChunking("Id")
+	ch.SetChunkSize(300 * 1024)
+
+	large := blob{"a", bytes.Repeat([]byte{1, 2, 3}, 200*1024)}
+	put := NewPut("tbl", large)
+	put.SetChunking(ch)
+	ok(t, put.Execute(tbl))
+	equals(t, 4, len(tbl.items))
+	equals(t, "3", aws.StringValue(tbl.items["a"][ChunksAttribute].N))
+	assert(t, tbl.items["a#chunk#3"] != nil, "expected the third chunk to exist")
+
+	var got blob
+	get := NewGet("tbl", map[string]string{"Id": "a"})
+	get.SetChunking(ch)
+	ok(t, get.Execute(tbl, &got))
+	equals(t, large, got)
+
+	//the projection is extended to recognize the manifest
+	get.SetProjectionExpression("Id, #d")
+	get.AddExpressionName("#d", "Data")
+	got = blob{}
+	ok(t, get.Execute(tbl, &got))
+	equals(t, large, got)
+
+	//replacing the item with a small one deletes the chunks
+	put.Item = blob{"a", []byte{1}}
+	ok(t, put.Execute(tbl))
+	equals(t, 1, len(tbl.items))
+	got = blob{}
+	ok(t, get.Execute(tbl, &got))
+	equals(t, []byte{1}, got.Data)
+
+	put.Item = large
+	ok(t, put.Execute(tbl))
+	del := NewDelete("tbl", map[string]string{"Id": "a"})
+	del.SetChunking(ch)
+	ok(t, del.Execute(tbl))
+	equals(t, 0, len(tbl.items))
+
+	//a failed condition of the manifest returns the condition error
+	ok(t, put.Execute(tbl))
+	put.SetConditionExpression("attribute_not_exists(Id)")
+	put.SetConditionError(errExists)
+	equals(t, errExists, put.Execute(tbl))
+
+	//without chunking the item is put as is, which DynamoDB would reject
+	ok(t, NewPut("tbl", blob{"b", large.Data}).Execute(tbl))
+	equals(t, large.Data, tbl.items["b"]["Data"].B)
+}
+
+func TestChunkingConcurrentWrites(t *testing.T) {
+	tbl := newMemTable()
+	db := NewDB(tbl)
+	ch := NewChunking("Id")
+	ch.SetChunkSize(300 * 1024)
+
+	large := blob{"a", bytes.Repeat([]byte{1, 2, 3}, 200*1024)}
+	put := db.Put("tbl", large)
+	put.SetChunking(ch)
+	ok(t, put.Execute(db))
+	stored := map[string]map[string]*dynamodb.AttributeValue{}
+	for id, item := range tbl.items {
+		stored[id] = item
+	}
+
+	chunked := func() {
+		tbl.items = map[string]map[string]*dynamodb.AttributeValue{}
+		for id, item := range stored {
+			tbl.items[id] = item
+		}
+	}
+
+	//the item is chunked after its chunk count was read, the put is retried
+	//such that it deletes the chunks
+	small := db.Put("tbl", blob{"a", []byte{1}})
+	small.SetChunking(ch)
+	ok(t, small.Execute(db))
+	tbl.race = chunked
+	ok(t, small.Execute(db))
+	equals(t, 1, len(tbl.items))
+	equals(t, []byte{1}, tbl.items["a"]["Data"].B)
+
+	//the item is replaced after its manifest was read, the replacement is
+	//read in the same transaction as the chunks
+	chunked()
+	get := db.Get("tbl", map[string]string{"Id": "a"})
+	get.SetChunking(ch)
+	tbl.race = func() { tbl.items = map[string]map[string]*dynamodb.AttributeValue{"a": item("Id", "a")} }
+	var got blob
+	ok(t, get.Execute(db, &got))
+	equals(t, blob{Id: "a"}, got)
+
+	chunked()
+	tbl.race = func() { tbl.items = map[string]map[string]*dynamodb.AttributeValue{} }
+	get.SetItemNilError(errExists)
+	equals(t, errExists, get.Execute(db, &got))
+
+	//transactions pass through the DB, interceptors must implement them
+	db.Use(func(next Client) Client { return &countingClient{Client: next} })
+	err := put.Execute(db)
+	assert(t, err != nil && strings.Contains(err.Error(), "doesn't implement TransactWriteItemsWithContext"), "expected unimplemented error, got: %v", err)
+}
+
+func TestItemSize(t *testing.T) {
+	item, err := marshalItem(map[string]interface{}{"Name": "abc", "Nums": []int{1, 123456}, "Nested": map[string]bool{"ok": true}}, nil)
+	ok(t, err)
+	equals(t, 4+3+4+(3+2+1+4)+6+(3+2+1+1), itemSize(item))
+}
@@ -0,0 +1,24 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//Client is the part of the DynamoDB API that the builders execute against. It
+//is satisfied by the dynamodbiface.DynamoDBAPI of the v1 SDK as is, clients of
+//the v2 SDK can be adapted with the sdkv2 package.
+//
+//Builders create a fresh input for every request and are not modified when
+//executed, a configured builder can therefore be executed repeatedly and from
+//multiple goroutines as long as it isn't configured at the same time. Use
+//Clone to derive a builder that is configured differently.
+type Client interface {
+	GetItemWithContext(aws.Context, *dynamodb.GetItemInput, ...request.Option) (*dynamodb.GetItemOutput, error)
+	PutItemWithContext(aws.Context, *dynamodb.PutItemInput, ...request.Option) (*dynamodb.PutItemOutput, error)
+	UpdateItemWithContext(aws.Context, *dynamodb.UpdateItemInput, ...request.Option) (*dynamodb.UpdateItemOutput, error)
+	DeleteItemWithContext(aws.Context, *dynamodb.DeleteItemInput, ...request.Option) (*dynamodb.DeleteItemOutput, error)
+	QueryWithContext(aws.Context, *dynamodb.QueryInput, ...request.Option) (*dynamodb.QueryOutput, error)
+	ScanWithContext(aws.Context, *dynamodb.ScanInput, ...request.Option) (*dynamodb.ScanOutput, error)
+}
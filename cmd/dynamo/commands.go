@@ -0,0 +1,515 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/advanderveer/go-dynamo"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//errNotFound is returned when get doesn't find the item
+var errNotFound = errors.New("item not found")
+
+//flags holds the flags that are shared by the commands
+type flags struct {
+	*flag.FlagSet
+	conn   connection
+	table  string
+	format string
+	names  string
+	values string
+}
+
+//newFlags creates the flag set of a command, items are represented in the
+//provided format by default
+func newFlags(name, format string) *flags {
+	f := &flags{FlagSet: flag.NewFlagSet("dynamo "+name, flag.ContinueOnError)}
+	f.StringVar(&f.table, "table", "", "name of the table (required)")
+	f.StringVar(&f.conn.Region, "region", "", "AWS region, defaults to the shared configuration")
+	f.StringVar(&f.conn.Endpoint, "endpoint", "", "endpoint URL, e.g. of a local stand-in")
+	f.StringVar(&f.conn.Profile, "profile", "", "profile of the shared configuration")
+	f.StringVar(&f.format, "format", format, "JSON format of items, keys and values: 'plain' or 'dynamodb'")
+	f.StringVar(&f.names, "names", "", `expression attribute names as a JSON object, e.g. {"#n":"Name"}`)
+	return f
+}
+
+//withValues adds the flag for expression attribute values
+func (f *flags) withValues() *flags {
+	f.StringVar(&f.values, "values", "", `expression attribute values as a JSON object, e.g. {":n":"a"}`)
+	return f
+}
+
+//parse parses the arguments and connects to DynamoDB
+func (f *flags) parse(e *env, args []string) (dynamo.Client, error) {
+	if err := f.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if f.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", f.Args())
+	}
+
+	if f.table == "" {
+		return nil, fmt.Errorf("the -table flag is required")
+	}
+
+	if _, err := f.itemFormat(); err != nil {
+		return nil, err
+	}
+
+	return e.connect(f.conn)
+}
+
+//itemFormat returns the configured item format
+func (f *flags) itemFormat() (dynamo.ItemFormat, error) {
+	switch f.format {
+	case "plain":
+		return dynamo.FormatPlain, nil
+	case "dynamodb":
+		return dynamo.FormatDynamoDB, nil
+	default:
+		return 0, fmt.Errorf("unknown format '%s', expected 'plain' or 'dynamodb'", f.format)
+	}
+}
+
+//decode decodes the JSON object of a flag in the configured format, a value
+//of '-' is read from stdin
+func (f *flags) decode(e *env, name, s string) (map[string]*dynamodb.AttributeValue, error) {
+	if s == "" {
+		return nil, fmt.Errorf("the -%s flag is required", name)
+	}
+
+	data := []byte(s)
+	if s == "-" {
+		var err error
+		if data, err = ioutil.ReadAll(e.stdin); err != nil {
+			return nil, fmt.Errorf("failed to read %s from stdin: %+v", name, err)
+		}
+	}
+
+	format, _ := f.itemFormat()
+	m, err := dynamo.DecodeItem(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %+v", name, err)
+	}
+
+	return m, nil
+}
+
+//expressions adds the configured expression names and values
+func (f *flags) expressions(e *env, eh *dynamo.ExpressionHolder) error {
+	if f.names != "" {
+		names := map[string]string{}
+		if err := json.Unmarshal([]byte(f.names), &names); err != nil {
+			return fmt.Errorf("failed to decode names: %+v", err)
+		}
+
+		for placeholder, name := range names {
+			eh.AddExpressionName(placeholder, name)
+		}
+	}
+
+	if f.values != "" {
+		values, err := f.decode(e, "values", f.values)
+		if err != nil {
+			return err
+		}
+
+		for placeholder, val := range values {
+			eh.AddExpressionValue(placeholder, val)
+		}
+	}
+
+	return nil
+}
+
+//write encodes an item on a line of its own
+func (f *flags) write(w io.Writer, item map[string]*dynamodb.AttributeValue) error {
+	format, _ := f.itemFormat()
+	data, err := dynamo.EncodeItem(format, item)
+	if err != nil {
+		return fmt.Errorf("failed to encode item: %+v", err)
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func runGet(e *env, args []string) error {
+	f := newFlags("get", "plain")
+	key := f.String("key", "", "primary key of the item as a JSON object (required)")
+	projection := f.String("projection", "", "projection expression")
+	consistent := f.Bool("consistent", false, "read strongly consistent")
+	db, err := f.parse(e, args)
+	if err != nil {
+		return err
+	}
+
+	pk, err := f.decode(e, "key", *key)
+	if err != nil {
+		return err
+	}
+
+	get := dynamo.NewGet(f.table, pk)
+	get.SetItemNilError(errNotFound)
+	if *projection != "" {
+		get.SetProjectionExpression(*projection)
+	}
+
+	if *consistent {
+		get.SetConsistentRead(true)
+	}
+
+	if err = f.expressions(e, &get.ExpressionHolder); err != nil {
+		return err
+	}
+
+	var item map[string]*dynamodb.AttributeValue
+	if err = get.ExecuteWithContext(aws.BackgroundContext(), db, &item); err != nil {
+		return err
+	}
+
+	return f.write(e.stdout, item)
+}
+
+func runPut(e *env, args []string) error {
+	f := newFlags("put", "plain").withValues()
+	it := f.String("item", "", "the item as a JSON object, '-' reads it from stdin (required)")
+	condition := f.String("condition", "", "condition expression")
+	db, err := f.parse(e, args)
+	if err != nil {
+		return err
+	}
+
+	item, err := f.decode(e, "item", *it)
+	if err != nil {
+		return err
+	}
+
+	put := dynamo.NewPut(f.table, item)
+	if *condition != "" {
+		put.SetConditionExpression(*condition)
+	}
+
+	if err = f.expressions(e, &put.ExpressionHolder); err != nil {
+		return err
+	}
+
+	return put.ExecuteWithContext(aws.BackgroundContext(), db)
+}
+
+func runUpdate(e *env, args []string) error {
+	f := newFlags("update", "plain").withValues()
+	key := f.String("key", "", "primary key of the item as a JSON object (required)")
+	update := f.String("update", "", "update expression (required)")
+	condition := f.String("condition", "", "condition expression")
+	db, err := f.parse(e, args)
+	if err != nil {
+		return err
+	}
+
+	pk, err := f.decode(e, "key", *key)
+	if err != nil {
+		return err
+	}
+
+	if *update == "" {
+		return fmt.Errorf("the -update flag is required")
+	}
+
+	upd := dynamo.NewUpdate(f.table, pk)
+	upd.SetUpdateExpression(*update)
+	if *condition != "" {
+		upd.SetConditionExpression(*condition)
+	}
+
+	if err = f.expressions(e, &upd.ExpressionHolder); err != nil {
+		return err
+	}
+
+	return upd.ExecuteWithContext(aws.BackgroundContext(), db)
+}
+
+func runDelete(e *env, args []string) error {
+	f := newFlags("delete", "plain").withValues()
+	key := f.String("key", "", "primary key of the item as a JSON object (required)")
+	condition := f.String("condition", "", "condition expression")
+	db, err := f.parse(e, args)
+	if err != nil {
+		return err
+	}
+
+	pk, err := f.decode(e, "key", *key)
+	if err != nil {
+		return err
+	}
+
+	del := dynamo.NewDelete(f.table, pk)
+	if *condition != "" {
+		del.SetConditionExpression(*condition)
+	}
+
+	if err = f.expressions(e, &del.ExpressionHolder); err != nil {
+		return err
+	}
+
+	return del.ExecuteWithContext(aws.BackgroundContext(), db)
+}
+
+func runQuery(e *env, args []string) error {
+	f := newFlags("query", "plain").withValues()
+	kcond := f.String("key-condition", "", "key condition expression (required)")
+	index := f.String("index", "", "name of the index to query")
+	filter := f.String("filter", "", "filter expression")
+	projection := f.String("projection", "", "projection expression")
+	limit := f.Int("limit", 0, "maximum number of items, all items are returned when 0")
+	consistent := f.Bool("consistent", false, "read strongly consistent")
+	desc := f.Bool("desc", false, "return the items in descending order of the sort key")
+	db, err := f.parse(e, args)
+	if err != nil {
+		return err
+	}
+
+	if *kcond == "" {
+		return fmt.Errorf("the -key-condition flag is required")
+	}
+
+	q := dynamo.NewQuery(f.table, *kcond)
+	q.SetMaxPages(dynamo.AllPages)
+	if *limit > 0 {
+		q.SetMaxItems(*limit)
+	}
+
+	if *index != "" {
+		q.SetIndexName(*index)
+	}
+
+	if *filter != "" {
+		q.SetFilterExpression(*filter)
+	}
+
+	if *projection != "" {
+		q.SetProjectionExpression(*projection)
+	}
+
+	if *consistent {
+		q.SetConsistentRead(true)
+	}
+
+	if *desc {
+		q.SetScanIndexForward(false)
+	}
+
+	if err = f.expressions(e, &q.ExpressionHolder); err != nil {
+		return err
+	}
+
+	var items []map[string]*dynamodb.AttributeValue
+	if _, err = q.ExecuteWithContext(aws.BackgroundContext(), db, &items); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err = f.write(e.stdout, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runScan(e *env, args []string) error {
+	f := newFlags("scan", "plain").withValues()
+	index := f.String("index", "", "name of the index to scan")
+	filter := f.String("filter", "", "filter expression")
+	projection := f.String("projection", "", "projection expression")
+	limit := f.Int("limit", 0, "maximum number of items, all items are returned when 0")
+	consistent := f.Bool("consistent", false, "read strongly consistent")
+	db, err := f.parse(e, args)
+	if err != nil {
+		return err
+	}
+
+	s := dynamo.NewScan(f.table)
+	s.SetMaxPages(dynamo.AllPages)
+	if *limit > 0 {
+		s.SetMaxItems(*limit)
+	}
+
+	if *index != "" {
+		s.SetIndexName(*index)
+	}
+
+	if *filter != "" {
+		s.SetFilterExpression(*filter)
+	}
+
+	if *projection != "" {
+		s.SetProjectionExpression(*projection)
+	}
+
+	if *consistent {
+		s.SetConsistentRead(true)
+	}
+
+	if err = f.expressions(e, &s.ExpressionHolder); err != nil {
+		return err
+	}
+
+	var items []map[string]*dynamodb.AttributeValue
+	if _, err = s.ExecuteWithContext(aws.BackgroundContext(), db, &items); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if err = f.write(e.stdout, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runCount(e *env, args []string) error {
+	f := newFlags("count", "plain").withValues()
+	kcond := f.String("key-condition", "", "key condition expression, the table or index is scanned when empty")
+	index := f.String("index", "", "name of the index to count")
+	filter := f.String("filter", "", "filter expression")
+	segments := f.Int64("segments", 0, "number of segments to scan in parallel")
+	consistent := f.Bool("consistent", false, "read strongly consistent")
+	db, err := f.parse(e, args)
+	if err != nil {
+		return err
+	}
+
+	var res struct{ Count, ScannedCount int64 }
+	if *kcond != "" {
+		q := dynamo.NewQuery(f.table, *kcond)
+		if *index != "" {
+			q.SetIndexName(*index)
+		}
+
+		if *filter != "" {
+			q.SetFilterExpression(*filter)
+		}
+
+		if *consistent {
+			q.SetConsistentRead(true)
+		}
+
+		if err = f.expressions(e, &q.ExpressionHolder); err != nil {
+			return err
+		}
+
+		if res.Count, res.ScannedCount, err = q.Count(aws.BackgroundContext(), db); err != nil {
+			return err
+		}
+	} else {
+		s := dynamo.NewScan(f.table)
+		if *index != "" {
+			s.SetIndexName(*index)
+		}
+
+		if *filter != "" {
+			s.SetFilterExpression(*filter)
+		}
+
+		if *segments > 1 {
+			s.SetTotalSegments(*segments)
+		}
+
+		if *consistent {
+			s.SetConsistentRead(true)
+		}
+
+		if err = f.expressions(e, &s.ExpressionHolder); err != nil {
+			return err
+		}
+
+		if res.Count, res.ScannedCount, err = s.Count(aws.BackgroundContext(), db); err != nil {
+			return err
+		}
+	}
+
+	return json.NewEncoder(e.stdout).Encode(res)
+}
+
+func runExport(e *env, args []string) error {
+	f := newFlags("export", "dynamodb").withValues()
+	index := f.String("index", "", "name of the index to export")
+	filter := f.String("filter", "", "filter expression")
+	out := f.String("out", "", "file to write the items to, defaults to stdout")
+	checkpoint := f.String("checkpoint", "", "file that records the progress, an interrupted export resumes from it")
+	db, err := f.parse(e, args)
+	if err != nil {
+		return err
+	}
+
+	format, _ := f.itemFormat()
+	ex := dynamo.NewExporter(f.table)
+	ex.SetFormat(format)
+	if *index != "" {
+		ex.SetIndexName(*index)
+	}
+
+	if *filter != "" {
+		ex.SetFilterExpression(*filter)
+	}
+
+	if err = f.expressions(e, &ex.ExpressionHolder); err != nil {
+		return err
+	}
+
+	mode := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if *checkpoint != "" {
+		data, err := ioutil.ReadFile(*checkpoint)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read checkpoint: %+v", err)
+		}
+
+		if err == nil {
+			var cp dynamo.Checkpoint
+			if err = json.Unmarshal(data, &cp); err != nil {
+				return fmt.Errorf("failed to decode checkpoint: %+v", err)
+			}
+
+			ex.SetCheckpoint(cp)
+			mode = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		}
+
+		ex.SetOnCheckpoint(func(cp dynamo.Checkpoint) error {
+			data, err := json.Marshal(cp)
+			if err != nil {
+				return err
+			}
+
+			tmp := *checkpoint + ".tmp"
+			if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+				return err
+			}
+
+			return os.Rename(tmp, *checkpoint)
+		})
+	}
+
+	w := e.stdout
+	if *out != "" {
+		file, err := os.OpenFile(*out, mode, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open output: %+v", err)
+		}
+
+		defer file.Close()
+		w = file
+	}
+
+	_, err = ex.Execute(aws.BackgroundContext(), db, w)
+	return err
+}
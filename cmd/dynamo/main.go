@@ -0,0 +1,117 @@
+//Command dynamo performs ad-hoc operations on DynamoDB tables using the
+//builders of the dynamo package. Items, keys and expression values are read
+//and written as JSON, e.g.:
+//
+//	dynamo get -table scores -key '{"GameTitle":"Alien Adventure","UserId":"User-5"}'
+//	dynamo query -table scores -key-condition '#t = :t' -names '{"#t":"GameTitle"}' -values '{":t":"Alien Adventure"}'
+//	dynamo export -table scores -endpoint http://localhost:8000 -out scores.jsonl
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/advanderveer/go-dynamo"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//connection configures how the DynamoDB API is reached
+type connection struct {
+	Region   string
+	Endpoint string
+	Profile  string
+}
+
+//env holds what commands interact with, such that they can be tested
+type env struct {
+	stdin   io.Reader
+	stdout  io.Writer
+	connect func(conn connection) (dynamo.Client, error)
+}
+
+//command is a subcommand that runs with its arguments
+type command struct {
+	usage string
+	run   func(e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"get":    {"get an item by its key", runGet},
+	"put":    {"put an item", runPut},
+	"update": {"update an item by its key with an update expression", runUpdate},
+	"delete": {"delete an item by its key", runDelete},
+	"query":  {"query items by a key condition, one item per line", runQuery},
+	"scan":   {"scan items, one item per line", runScan},
+	"count":  {"count the items that match a query or scan", runCount},
+	"export": {"export all items of a table as JSON Lines", runExport},
+}
+
+func main() {
+	e := &env{stdin: os.Stdin, stdout: os.Stdout, connect: connect}
+	if err := run(e, os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(2)
+		}
+
+		fmt.Fprintf(os.Stderr, "dynamo: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+//run dispatches the arguments to a subcommand
+func run(e *env, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("no command provided\n\n%s", usage())
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command '%s'\n\n%s", args[0], usage())
+	}
+
+	return cmd.run(e, args[1:])
+}
+
+//usage lists the available commands
+func usage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	s := "usage: dynamo <command> [flags], where command is one of:\n"
+	for _, name := range names {
+		s += fmt.Sprintf("  %-8s %s\n", name, commands[name].usage)
+	}
+
+	return s + "\nuse 'dynamo <command> -h' for the flags of a command"
+}
+
+//connect creates a client from the shared configuration and the environment
+func connect(conn connection) (dynamo.Client, error) {
+	cfg := aws.NewConfig()
+	if conn.Region != "" {
+		cfg.WithRegion(conn.Region)
+	}
+
+	if conn.Endpoint != "" {
+		cfg.WithEndpoint(conn.Endpoint)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		Profile:           conn.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %+v", err)
+	}
+
+	return dynamodb.New(sess), nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/advanderveer/go-dynamo"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+//fakeDB records the inputs and serves the configured outputs
+type fakeDB struct {
+	dynamodbiface.DynamoDBAPI
+	inputs []interface{}
+	item   map[string]*dynamodb.AttributeValue
+	pages  [][]map[string]*dynamodb.AttributeValue
+}
+
+func (db *fakeDB) GetItemWithContext(ctx aws.Context, in *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	db.inputs = append(db.inputs, in)
+	return &dynamodb.GetItemOutput{Item: db.item}, nil
+}
+
+func (db *fakeDB) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	db.inputs = append(db.inputs, in)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (db *fakeDB) ScanWithContext(ctx aws.Context, in *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	db.inputs = append(db.inputs, in)
+	page := 0
+	if in.ExclusiveStartKey != nil {
+		page = 1
+	}
+
+	out := &dynamodb.ScanOutput{Items: db.pages[page], Count: aws.Int64(int64(len(db.pages[page])))}
+	if page+1 < len(db.pages) {
+		out.LastEvaluatedKey = db.pages[page][len(db.pages[page])-1]
+	}
+
+	return out, nil
+}
+
+func (db *fakeDB) QueryWithContext(ctx aws.Context, in *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	db.inputs = append(db.inputs, in)
+	return &dynamodb.QueryOutput{Items: db.pages[0], Count: aws.Int64(int64(len(db.pages[0])))}, nil
+}
+
+func runWith(db *fakeDB, stdin string, args ...string) (string, error) {
+	out := bytes.NewBuffer(nil)
+	err := run(&env{
+		stdin:   strings.NewReader(stdin),
+		stdout:  out,
+		connect: func(conn connection) (dynamo.Client, error) { return db, nil },
+	}, args)
+
+	return out.String(), err
+}
+
+func TestGetPut(t *testing.T) {
+	db := &fakeDB{item: map[string]*dynamodb.AttributeValue{
+		"Id":    {S: aws.String("a")},
+		"Score": {N: aws.String("100.50")},
+	}}
+
+	out, err := runWith(db, "", "get", "-table", "tbl", "-key", `{"Id":"a"}`)
+	ok(t, err)
+	equals(t, `{"Id":"a","Score":100.50}`+"\n", out)
+	equals(t, map[string]*dynamodb.AttributeValue{"Id": {S: aws.String("a")}}, db.inputs[0].(*dynamodb.GetItemInput).Key)
+
+	_, err = runWith(db, `{"Id":{"S":"b"},"Tags":{"SS":["x"]}}`, "put", "-table", "tbl", "-format", "dynamodb", "-item", "-",
+		"-condition", "attribute_not_exists(#id) OR #v < :v", "-names", `{"#id":"Id","#v":"Version"}`, "-values", `{":v":{"N":"2"}}`)
+	ok(t, err)
+
+	in := db.inputs[1].(*dynamodb.PutItemInput)
+	equals(t, []*string{aws.String("x")}, in.Item["Tags"].SS)
+	equals(t, map[string]*dynamodb.AttributeValue{":v": {N: aws.String("2")}}, in.ExpressionAttributeValues)
+	equals(t, "Version", aws.StringValue(in.ExpressionAttributeNames["#v"]))
+
+	db.item = nil
+	_, err = runWith(db, "", "get", "-table", "tbl", "-key", `{"Id":"c"}`)
+	equals(t, errNotFound, err)
+
+	_, err = runWith(db, "", "get", "-key", `{"Id":"c"}`)
+	equals(t, "the -table flag is required", err.Error())
+
+	_, err = runWith(db, "", "truncate")
+	equals(t, true, strings.HasPrefix(err.Error(), "unknown command 'truncate'"))
+}
+
+func TestScanExport(t *testing.T) {
+	db := &fakeDB{pages: [][]map[string]*dynamodb.AttributeValue{
+		{{"Id": {S: aws.String("a")}}},
+		{{"Id": {S: aws.String("b")}}},
+	}}
+
+	out, err := runWith(db, "", "scan", "-table", "tbl", "-filter", "#id <> :id", "-names", `{"#id":"Id"}`, "-values", `{":id":"c"}`)
+	ok(t, err)
+	equals(t, "{\"Id\":\"a\"}\n{\"Id\":\"b\"}\n", out)
+
+	dir, err := ioutil.TempDir("", "dynamo")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	file, cp := filepath.Join(dir, "tbl.jsonl"), filepath.Join(dir, "tbl.checkpoint")
+	_, err = runWith(db, "", "export", "-table", "tbl", "-out", file, "-checkpoint", cp)
+	ok(t, err)
+
+	data, err := ioutil.ReadFile(file)
+	ok(t, err)
+	equals(t, "{\"Id\":{\"S\":\"a\"}}\n{\"Id\":{\"S\":\"b\"}}\n", string(data))
+
+	data, err = ioutil.ReadFile(cp)
+	ok(t, err)
+	equals(t, `{"Lines":2}`, string(data))
+}
+
+func TestQueryScanLimit(t *testing.T) {
+	db := &fakeDB{pages: [][]map[string]*dynamodb.AttributeValue{
+		{{"Id": {S: aws.String("a")}}, {"Id": {S: aws.String("b")}}, {"Id": {S: aws.String("c")}}},
+	}}
+
+	out, err := runWith(db, "", "scan", "-table", "tbl", "-limit", "2")
+	ok(t, err)
+	equals(t, "{\"Id\":\"a\"}\n{\"Id\":\"b\"}\n", out)
+
+	out, err = runWith(db, "", "query", "-table", "tbl", "-key-condition", "#id = :id", "-names", `{"#id":"Id"}`,
+		"-values", `{":id":"a"}`, "-limit", "1")
+	ok(t, err)
+	equals(t, "{\"Id\":\"a\"}\n", out)
+}
@@ -0,0 +1,404 @@
+//Package codec converts items between attribute value maps, the DynamoDB
+//JSON wire format (e.g. {"Name":{"S":"a"}}) and plain JSON (e.g. {"Name":"a"}).
+//Numbers keep their precision in both formats. The DynamoDB JSON format
+//preserves all types, plain JSON represents binary values as base64 strings
+//and sets as arrays such that they are decoded as strings and lists.
+package codec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//Item is an attribute value map that the json package encodes as DynamoDB
+//JSON, e.g. for embedding items in other JSON documents
+type Item map[string]*dynamodb.AttributeValue
+
+//MarshalJSON encodes the item as DynamoDB JSON
+func (it Item) MarshalJSON() ([]byte, error) {
+	if it == nil {
+		return []byte("null"), nil
+	}
+
+	return Marshal(it)
+}
+
+//UnmarshalJSON decodes the item from DynamoDB JSON
+func (it *Item) UnmarshalJSON(data []byte) (err error) {
+	if string(bytes.TrimSpace(data)) == "null" {
+		*it = nil
+		return nil
+	}
+
+	*it, err = Unmarshal(data)
+	return err
+}
+
+//Marshal encodes an item as DynamoDB JSON
+func Marshal(item map[string]*dynamodb.AttributeValue) ([]byte, error) {
+	return marshalItem(item, typedValue)
+}
+
+//Unmarshal decodes an item from DynamoDB JSON
+func Unmarshal(data []byte) (map[string]*dynamodb.AttributeValue, error) {
+	return unmarshalItem(data, fromTypedValue)
+}
+
+//MarshalValue encodes a single attribute value as DynamoDB JSON
+func MarshalValue(av *dynamodb.AttributeValue) ([]byte, error) {
+	v, err := typedValue(av)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+//UnmarshalValue decodes a single attribute value from DynamoDB JSON
+func UnmarshalValue(data []byte) (*dynamodb.AttributeValue, error) {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromTypedValue(v)
+}
+
+//MarshalPlain encodes an item as plain JSON
+func MarshalPlain(item map[string]*dynamodb.AttributeValue) ([]byte, error) {
+	return marshalItem(item, plainValue)
+}
+
+//UnmarshalPlain decodes an item from plain JSON, null becomes a NULL attribute
+func UnmarshalPlain(data []byte) (map[string]*dynamodb.AttributeValue, error) {
+	return unmarshalItem(data, fromPlainValue)
+}
+
+//MarshalPlainValue encodes a single attribute value as plain JSON
+func MarshalPlainValue(av *dynamodb.AttributeValue) ([]byte, error) {
+	v, err := plainValue(av)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+//UnmarshalPlainValue decodes a single attribute value from plain JSON
+func UnmarshalPlainValue(data []byte) (*dynamodb.AttributeValue, error) {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromPlainValue(v)
+}
+
+//marshalItem converts each attribute and encodes the result as JSON
+func marshalItem(item map[string]*dynamodb.AttributeValue, conv func(*dynamodb.AttributeValue) (interface{}, error)) ([]byte, error) {
+	m := make(map[string]interface{}, len(item))
+	for name, av := range item {
+		var err error
+		if m[name], err = conv(av); err != nil {
+			return nil, fmt.Errorf("failed to encode attribute '%s': %+v", name, err)
+		}
+	}
+
+	return json.Marshal(m)
+}
+
+//unmarshalItem decodes a JSON object and converts each of its attributes
+func unmarshalItem(data []byte, conv func(interface{}) (*dynamodb.AttributeValue, error)) (map[string]*dynamodb.AttributeValue, error) {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("item must be a JSON object")
+	}
+
+	item := make(map[string]*dynamodb.AttributeValue, len(m))
+	for name, v := range m {
+		if item[name], err = conv(v); err != nil {
+			return nil, fmt.Errorf("failed to decode attribute '%s': %+v", name, err)
+		}
+	}
+
+	return item, nil
+}
+
+//decodeJSON decodes a JSON document with numbers as json.Number, trailing
+//data is an error
+func decodeJSON(data []byte) (v interface{}, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err = dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+
+	return v, nil
+}
+
+//typedValue converts an attribute value into its DynamoDB JSON representation
+func typedValue(av *dynamodb.AttributeValue) (interface{}, error) {
+	switch {
+	case av == nil:
+		return nil, fmt.Errorf("attribute value is nil")
+	case av.S != nil:
+		return map[string]interface{}{"S": *av.S}, nil
+	case av.N != nil:
+		return map[string]interface{}{"N": *av.N}, nil
+	case av.B != nil:
+		return map[string]interface{}{"B": av.B}, nil
+	case av.BOOL != nil:
+		return map[string]interface{}{"BOOL": *av.BOOL}, nil
+	case av.NULL != nil:
+		return map[string]interface{}{"NULL": *av.NULL}, nil
+	case av.SS != nil:
+		return map[string]interface{}{"SS": aws.StringValueSlice(av.SS)}, nil
+	case av.NS != nil:
+		return map[string]interface{}{"NS": aws.StringValueSlice(av.NS)}, nil
+	case av.BS != nil:
+		return map[string]interface{}{"BS": av.BS}, nil
+	case av.M != nil:
+		m := make(map[string]interface{}, len(av.M))
+		for k, v := range av.M {
+			var err error
+			if m[k], err = typedValue(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return map[string]interface{}{"M": m}, nil
+	case av.L != nil:
+		l := make([]interface{}, len(av.L))
+		for i, v := range av.L {
+			var err error
+			if l[i], err = typedValue(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return map[string]interface{}{"L": l}, nil
+	default:
+		return nil, fmt.Errorf("attribute value has no type")
+	}
+}
+
+//fromTypedValue converts a decoded DynamoDB JSON value into an attribute value
+func fromTypedValue(v interface{}) (*dynamodb.AttributeValue, error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok || len(obj) != 1 {
+		return nil, fmt.Errorf("expected an object with a single type descriptor, got: %v", v)
+	}
+
+	for typ, val := range obj {
+		av := &dynamodb.AttributeValue{}
+		switch typ {
+		case "S", "N":
+			s, ok := val.(string)
+			if n, isNum := val.(json.Number); isNum && typ == "N" {
+				s, ok = n.String(), true
+			}
+
+			if !ok {
+				return nil, fmt.Errorf("expected a string for '%s', got: %v", typ, val)
+			}
+
+			if typ == "S" {
+				av.S = aws.String(s)
+			} else {
+				av.N = aws.String(s)
+			}
+		case "B":
+			b, err := base64Value(val)
+			if err != nil {
+				return nil, err
+			}
+
+			av.B = b
+		case "BOOL", "NULL":
+			b, ok := val.(bool)
+			if !ok {
+				return nil, fmt.Errorf("expected a boolean for '%s', got: %v", typ, val)
+			}
+
+			if typ == "BOOL" {
+				av.BOOL = aws.Bool(b)
+			} else {
+				av.NULL = aws.Bool(b)
+			}
+		case "SS", "NS", "BS", "L":
+			l, ok := val.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected an array for '%s', got: %v", typ, val)
+			}
+
+			if len(l) == 0 && typ != "L" {
+				return nil, fmt.Errorf("set '%s' is empty, DynamoDB doesn't support empty sets", typ)
+			}
+
+			for _, e := range l {
+				switch typ {
+				case "SS", "NS":
+					s, ok := e.(string)
+					if !ok {
+						return nil, fmt.Errorf("expected strings in '%s', got: %v", typ, e)
+					}
+
+					if typ == "SS" {
+						av.SS = append(av.SS, aws.String(s))
+					} else {
+						av.NS = append(av.NS, aws.String(s))
+					}
+				case "BS":
+					b, err := base64Value(e)
+					if err != nil {
+						return nil, err
+					}
+
+					av.BS = append(av.BS, b)
+				default:
+					ev, err := fromTypedValue(e)
+					if err != nil {
+						return nil, err
+					}
+
+					av.L = append(av.L, ev)
+				}
+			}
+
+			if typ == "L" && av.L == nil {
+				av.L = []*dynamodb.AttributeValue{}
+			}
+		case "M":
+			m, ok := val.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected an object for 'M', got: %v", val)
+			}
+
+			av.M = make(map[string]*dynamodb.AttributeValue, len(m))
+			for k, e := range m {
+				var err error
+				if av.M[k], err = fromTypedValue(e); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown type descriptor '%s'", typ)
+		}
+
+		return av, nil
+	}
+
+	return nil, nil
+}
+
+//base64Value decodes a base64 encoded JSON string
+func base64Value(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a base64 string, got: %v", v)
+	}
+
+	return base64.StdEncoding.DecodeString(s)
+}
+
+//plainValue converts an attribute value into plain JSON, numbers are kept as
+//json.Number such that their precision is preserved
+func plainValue(av *dynamodb.AttributeValue) (interface{}, error) {
+	switch {
+	case av == nil:
+		return nil, fmt.Errorf("attribute value is nil")
+	case av.S != nil:
+		return *av.S, nil
+	case av.N != nil:
+		return json.Number(*av.N), nil
+	case av.B != nil:
+		return av.B, nil
+	case av.BOOL != nil:
+		return *av.BOOL, nil
+	case av.NULL != nil:
+		return nil, nil
+	case av.SS != nil:
+		return aws.StringValueSlice(av.SS), nil
+	case av.NS != nil:
+		l := make([]json.Number, len(av.NS))
+		for i, n := range av.NS {
+			l[i] = json.Number(aws.StringValue(n))
+		}
+
+		return l, nil
+	case av.BS != nil:
+		return av.BS, nil
+	case av.M != nil:
+		m := make(map[string]interface{}, len(av.M))
+		for k, v := range av.M {
+			var err error
+			if m[k], err = plainValue(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+	case av.L != nil:
+		l := make([]interface{}, len(av.L))
+		for i, v := range av.L {
+			var err error
+			if l[i], err = plainValue(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return l, nil
+	default:
+		return nil, fmt.Errorf("attribute value has no type")
+	}
+}
+
+//fromPlainValue converts a decoded plain JSON value into an attribute value
+func fromPlainValue(v interface{}) (*dynamodb.AttributeValue, error) {
+	switch v := v.(type) {
+	case nil:
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}, nil
+	case string:
+		return &dynamodb.AttributeValue{S: aws.String(v)}, nil
+	case json.Number:
+		return &dynamodb.AttributeValue{N: aws.String(v.String())}, nil
+	case bool:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(v)}, nil
+	case []interface{}:
+		av := &dynamodb.AttributeValue{L: make([]*dynamodb.AttributeValue, len(v))}
+		for i, e := range v {
+			var err error
+			if av.L[i], err = fromPlainValue(e); err != nil {
+				return nil, err
+			}
+		}
+
+		return av, nil
+	case map[string]interface{}:
+		av := &dynamodb.AttributeValue{M: make(map[string]*dynamodb.AttributeValue, len(v))}
+		for k, e := range v {
+			var err error
+			if av.M[k], err = fromPlainValue(e); err != nil {
+				return nil, err
+			}
+		}
+
+		return av, nil
+	default:
+		return nil, fmt.Errorf("unsupported JSON value: %T", v)
+	}
+}
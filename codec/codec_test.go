@@ -0,0 +1,82 @@
+package codec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var item = map[string]*dynamodb.AttributeValue{
+	"S":    {S: aws.String("a")},
+	"N":    {N: aws.String("12345678901234567890.5")},
+	"B":    {B: []byte("bin")},
+	"BOOL": {BOOL: aws.Bool(true)},
+	"NULL": {NULL: aws.Bool(true)},
+	"SS":   {SS: aws.StringSlice([]string{"x", "y"})},
+	"NS":   {NS: aws.StringSlice([]string{"1", "2"})},
+	"BS":   {BS: [][]byte{[]byte("z")}},
+	"M":    {M: map[string]*dynamodb.AttributeValue{"L": {L: []*dynamodb.AttributeValue{{S: aws.String("e")}}}}},
+	"E":    {L: []*dynamodb.AttributeValue{}},
+}
+
+func TestDynamoDBJSON(t *testing.T) {
+	data, err := Marshal(item)
+	ok(t, err)
+	equals(t, `{"B":{"B":"Ymlu"},"BOOL":{"BOOL":true},"BS":{"BS":["eg=="]},"E":{"L":[]},"M":{"M":{"L":{"L":[{"S":"e"}]}}},`+
+		`"N":{"N":"12345678901234567890.5"},"NS":{"NS":["1","2"]},"NULL":{"NULL":true},"S":{"S":"a"},"SS":{"SS":["x","y"]}}`, string(data))
+
+	back, err := Unmarshal(data)
+	ok(t, err)
+	equals(t, item, back)
+
+	av, err := UnmarshalValue([]byte(`{"N": 1.25}`))
+	ok(t, err)
+	equals(t, &dynamodb.AttributeValue{N: aws.String("1.25")}, av)
+
+	for _, invalid := range []string{`{"A":{"X":"a"}}`, `{"A":{"S":"a","N":"1"}}`, `{"A":{"BOOL":"true"}}`, `[]`, `{"A":{"S":"a"}} {}`} {
+		_, err = Unmarshal([]byte(invalid))
+		assert(t, err != nil, "expected %s to be invalid", invalid)
+	}
+
+	for _, typ := range []string{"SS", "NS", "BS"} {
+		_, err = Unmarshal([]byte(`{"A":{"` + typ + `":[]}}`))
+		assert(t, err != nil && err.Error() == "failed to decode attribute 'A': set '"+typ+"' is empty, DynamoDB doesn't support empty sets",
+			"expected empty %s to be invalid, got: %v", typ, err)
+	}
+}
+
+func TestPlainJSON(t *testing.T) {
+	data, err := MarshalPlain(item)
+	ok(t, err)
+	equals(t, `{"B":"Ymlu","BOOL":true,"BS":["eg=="],"E":[],"M":{"L":["e"]},"N":12345678901234567890.5,"NS":[1,2],"NULL":null,"S":"a","SS":["x","y"]}`, string(data))
+
+	back, err := UnmarshalPlain(data)
+	ok(t, err)
+	equals(t, item["N"], back["N"])
+	equals(t, item["M"], back["M"])
+	equals(t, item["NULL"], back["NULL"])
+	equals(t, &dynamodb.AttributeValue{S: aws.String("Ymlu")}, back["B"])
+	equals(t, &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{{S: aws.String("x")}, {S: aws.String("y")}}}, back["SS"])
+
+	data, err = MarshalPlainValue(&dynamodb.AttributeValue{N: aws.String("1e400")})
+	ok(t, err)
+	equals(t, "1e400", string(data))
+}
+
+func TestItemJSON(t *testing.T) {
+	doc := struct {
+		Keys     Item
+		OldImage Item `json:",omitempty"`
+	}{Keys: Item{"Id": {S: aws.String("a")}}}
+
+	data, err := json.Marshal(doc)
+	ok(t, err)
+	equals(t, `{"Keys":{"Id":{"S":"a"}}}`, string(data))
+
+	doc.Keys = nil
+	ok(t, json.Unmarshal([]byte(`{"Keys":{"Id":{"S":"b"}},"OldImage":null}`), &doc))
+	equals(t, Item{"Id": {S: aws.String("b")}}, doc.Keys)
+	equals(t, Item(nil), doc.OldImage)
+}
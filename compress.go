@@ -0,0 +1,92 @@
+package dynamo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+//Compressor compresses the attributes of fields tagged with the compress
+//option, e.g.:
+//
+//	type Document struct {
+//		Id   string
+//		Body string `dynamo:",compress"`      //gzip
+//		Meta Meta   `dynamo:",compress=zstd"` //a registered compressor, as JSON
+//	}
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var compressors = struct {
+	sync.RWMutex
+	m map[string]Compressor
+}{m: map[string]Compressor{"gzip": GzipCompressor{}}}
+
+//RegisterCompressor makes a compressor available to the compress option under
+//name, e.g. to use zstd. The "gzip" compressor is registered by default and
+//is used when the option doesn't name one.
+func RegisterCompressor(name string, c Compressor) {
+	compressors.Lock()
+	defer compressors.Unlock()
+	compressors.m[name] = c
+}
+
+//GzipCompressor compresses with gzip at the default compression level
+type GzipCompressor struct{}
+
+//Compress compresses data
+func (GzipCompressor) Compress(data []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+//Decompress decompresses data
+func (GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+//compressCoding applies a compressor to a field's data
+type compressCoding struct{ Compressor }
+
+func (c compressCoding) encode(fc fieldContext, data []byte) ([]byte, error) {
+	return c.Compress(data)
+}
+
+func (c compressCoding) decode(fc fieldContext, data []byte) ([]byte, error) {
+	return c.Decompress(data)
+}
+
+//newCompressCoding resolves the compressor that is named by the option
+func newCompressCoding(name string) (fieldCoding, error) {
+	if name == "" {
+		name = "gzip"
+	}
+
+	compressors.RLock()
+	defer compressors.RUnlock()
+	c, ok := compressors.m[name]
+	if !ok {
+		return nil, fmt.Errorf("no compressor registered as '%s'", name)
+	}
+
+	return compressCoding{c}, nil
+}
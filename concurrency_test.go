@@ -0,0 +1,112 @@
+package dynamo
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestConcurrentExecute(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{item("Name", "a"), item("Name", "b"), item("Name", "c")}
+	query := pages(2, items...)
+	db := &fakeDB{
+		getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: in.Key}, nil
+		},
+		putItem: func(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		updateItem: func(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+		deleteItem: func(in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+			return &dynamodb.DeleteItemOutput{}, nil
+		},
+		query: query,
+		scan: func(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+			out, err := query(&dynamodb.QueryInput{ExclusiveStartKey: in.ExclusiveStartKey})
+			return &dynamodb.ScanOutput{Items: out.Items, Count: out.Count, LastEvaluatedKey: out.LastEvaluatedKey}, err
+		},
+	}
+
+	get := NewGet("tbl", named{"a"})
+	get.SetProjectionExpression("#n")
+	get.AddExpressionName("#n", "Name")
+
+	put := NewPut("tbl", named{"a"})
+	put.SetConditionExpression("attribute_not_exists(Name)")
+
+	upd := NewUpdate("tbl", named{"a"})
+	upd.SetUpdateExpression("SET Score = :s")
+	upd.AddExpressionValue(":s", 1)
+
+	del := NewDelete("tbl", named{"a"})
+	del.SetConditionExpression("Score > :s")
+	del.AddExpressionValue(":s", 1)
+
+	q := NewQuery("tbl", "Name = :name")
+	q.AddExpressionValue(":name", "a")
+	q.SetMaxPages(AllPages)
+
+	scan := NewScan("tbl")
+	scan.SetMaxPages(AllPages)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 6*8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var got named
+			errs <- get.Execute(db, &got)
+			errs <- put.Execute(db)
+			errs <- upd.Execute(db)
+			errs <- del.Execute(db)
+
+			var list []named
+			_, err := q.Execute(db, &list)
+			errs <- err
+			list = nil
+			_, err = scan.Execute(db, &list)
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		ok(t, err)
+	}
+
+	equals(t, map[string]*dynamodb.AttributeValue(nil), get.Key)
+	equals(t, map[string]*dynamodb.AttributeValue(nil), put.PutItemInput.Item)
+	equals(t, map[string]*dynamodb.AttributeValue(nil), upd.ExpressionAttributeValues)
+	equals(t, map[string]*string(nil), get.ExpressionAttributeNames)
+}
+
+func TestClone(t *testing.T) {
+	q := NewQuery("tbl", "Name = :name")
+	q.AddExpressionValue(":name", "a")
+	q.SetKeyAttributes("Name", "Pos")
+	q.SetExclusiveStartKey(item("Name", "a"))
+
+	c := q.Clone()
+	c.AddExpressionValue(":name", "b")
+	c.KeyAttributes[0] = "Other"
+	c.ExclusiveStartKey["Name"].SetS("b")
+	c.SetKeyConditionExpression("Other = :name")
+
+	equals(t, "a", q.ExpAttrValues[":name"])
+	equals(t, []string{"Name", "Pos"}, q.KeyAttributes)
+	equals(t, item("Name", "a"), q.ExclusiveStartKey)
+	equals(t, "Name = :name", aws.StringValue(q.KeyConditionExpression))
+	equals(t, "b", c.ExpAttrValues[":name"])
+
+	upd := NewUpdate("tbl", named{"a"})
+	upd.AddExpressionName("#s", "Score")
+	cu := upd.Clone()
+	cu.AddExpressionName("#s", "Points")
+	equals(t, "Score", upd.ExpAttrNames["#s"])
+}
@@ -0,0 +1,60 @@
+package dynamo
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestQueryCount(t *testing.T) {
+	var inputs []*dynamodb.QueryInput
+	serve := pages(2, item("Name", "a"), item("Name", "b"), item("Name", "c"))
+	db := &fakeDB{query: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		inputs = append(inputs, in)
+		return serve(in)
+	}}
+
+	q := NewQuery("tbl", "#n = :name")
+	q.SetProjectionExpression("#n, #p")
+	q.AddExpressionName("#n", "Name")
+	q.AddExpressionName("#p", "Points")
+	q.AddExpressionValue(":name", "a")
+
+	n, scanned, err := q.Count(aws.BackgroundContext(), db)
+	ok(t, err)
+	equals(t, int64(3), n)
+	equals(t, int64(3), scanned)
+	equals(t, 2, len(inputs))
+	equals(t, dynamodb.SelectCount, aws.StringValue(inputs[0].Select))
+	equals(t, (*string)(nil), inputs[0].ProjectionExpression)
+	equals(t, map[string]*string{"#n": aws.String("Name")}, inputs[0].ExpressionAttributeNames)
+	equals(t, (*string)(nil), q.Select)
+}
+
+func TestScanCountParallel(t *testing.T) {
+	var mu sync.Mutex
+	segments := map[int64]int{}
+	db := &fakeDB{scan: func(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		seg := aws.Int64Value(in.Segment)
+		segments[seg]++
+
+		out := &dynamodb.ScanOutput{Count: aws.Int64(seg), ScannedCount: aws.Int64(10)}
+		if in.ExclusiveStartKey == nil {
+			out.LastEvaluatedKey = item("Name", "next")
+		}
+
+		return out, nil
+	}}
+
+	s := NewScan("tbl")
+	s.SetTotalSegments(4)
+	n, scanned, err := s.Count(aws.BackgroundContext(), db)
+	ok(t, err)
+	equals(t, int64(2*(0+1+2+3)), n)
+	equals(t, int64(80), scanned)
+	equals(t, map[int64]int{0: 2, 1: 2, 2: 2, 3: 2}, segments)
+}
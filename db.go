@@ -0,0 +1,217 @@
+package dynamo
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//Interceptor wraps the client that builders execute against, e.g. to add
+//logging, retries or request rewriting. DB passes operations outside of Client
+//(e.g. batch writes) on to the client it wraps, an interceptor that wraps such
+//operations must implement them as well (e.g. BatchWriteClient) to pass them on.
+type Interceptor func(next Client) Client
+
+//DB wraps a client together with defaults that are applied to every builder
+//it creates. It implements Client itself, such that the builders it creates
+//are executed through its interceptors.
+type DB struct {
+	Client
+	TracingInput
+	MetricsInput
+	EncryptionInput
+	TablePrefix            string
+	TableSuffix            string
+	TableNameResolver      func(name string) string
+	ConsistentRead         *bool
+	ReturnConsumedCapacity *string
+}
+
+//NewDB wraps the client, the v1 dynamodbiface.DynamoDBAPI can be passed as is
+func NewDB(c Client) *DB {
+	return &DB{Client: c}
+}
+
+//SetTablePrefix configures a prefix that is added to every table name, e.g. 'dev-'
+func (db *DB) SetTablePrefix(p string) { db.TablePrefix = p }
+
+//SetTableSuffix configures a suffix that is added to every table name
+func (db *DB) SetTableSuffix(s string) { db.TableSuffix = s }
+
+//SetTableNameResolver configures a function that maps table names as they are
+//used in code onto the name of the actual table, it takes precedence over the
+//prefix and suffix
+func (db *DB) SetTableNameResolver(fn func(name string) string) { db.TableNameResolver = fn }
+
+//SetConsistentRead configures the consistency of gets, queries and scans. Note
+//that queries on global secondary indexes don't support consistent reads.
+func (db *DB) SetConsistentRead(v bool) { db.ConsistentRead = &v }
+
+//SetReturnConsumedCapacity configures the level of detail about consumed
+//capacity that is returned for every request
+func (db *DB) SetReturnConsumedCapacity(v string) { db.ReturnConsumedCapacity = &v }
+
+//Use wraps the client with the interceptors, the last interceptor is called first
+func (db *DB) Use(interceptors ...Interceptor) {
+	for _, ic := range interceptors {
+		db.Client = ic(db.Client)
+	}
+}
+
+//TableName returns the actual table name for a table name as it is used in code
+func (db *DB) TableName(name string) string {
+	if db.TableNameResolver != nil {
+		return db.TableNameResolver(name)
+	}
+
+	return db.TablePrefix + name + db.TableSuffix
+}
+
+//Get prepares a get on the resolved table with the defaults applied
+func (db *DB) Get(tname string, pk interface{}) *Get {
+	inp := NewGet(db.TableName(tname), pk)
+	inp.Tracer, inp.Metrics = db.Tracer, db.Metrics
+	inp.Encryption = db.Encryption
+	inp.ConsistentRead = db.ConsistentRead
+	inp.ReturnConsumedCapacity = db.ReturnConsumedCapacity
+	return inp
+}
+
+//Put prepares a put on the resolved table with the defaults applied
+func (db *DB) Put(tname string, item interface{}) *Put {
+	inp := NewPut(db.TableName(tname), item)
+	inp.Tracer, inp.Metrics = db.Tracer, db.Metrics
+	inp.Encryption = db.Encryption
+	inp.ReturnConsumedCapacity = db.ReturnConsumedCapacity
+	return inp
+}
+
+//Update prepares an update on the resolved table with the defaults applied
+func (db *DB) Update(tname string, pk interface{}) *Update {
+	inp := NewUpdate(db.TableName(tname), pk)
+	inp.Tracer, inp.Metrics = db.Tracer, db.Metrics
+	inp.ReturnConsumedCapacity = db.ReturnConsumedCapacity
+	return inp
+}
+
+//Delete prepares a delete on the resolved table with the defaults applied
+func (db *DB) Delete(tname string, pk interface{}) *Delete {
+	inp := NewDelete(db.TableName(tname), pk)
+	inp.Tracer, inp.Metrics = db.Tracer, db.Metrics
+	inp.ReturnConsumedCapacity = db.ReturnConsumedCapacity
+	return inp
+}
+
+//Query prepares a query on the resolved table with the defaults applied
+func (db *DB) Query(tname, kcond string) *Query {
+	inp := NewQuery(db.TableName(tname), kcond)
+	inp.Tracer, inp.Metrics = db.Tracer, db.Metrics
+	inp.Encryption = db.Encryption
+	inp.ConsistentRead = db.ConsistentRead
+	inp.ReturnConsumedCapacity = db.ReturnConsumedCapacity
+	return inp
+}
+
+//Scan prepares a scan on the resolved table with the defaults applied
+func (db *DB) Scan(tname string) *Scan {
+	inp := NewScan(db.TableName(tname))
+	inp.Tracer, inp.Metrics = db.Tracer, db.Metrics
+	inp.Encryption = db.Encryption
+	inp.ConsistentRead = db.ConsistentRead
+	inp.ReturnConsumedCapacity = db.ReturnConsumedCapacity
+	return inp
+}
+
+//Exporter prepares an export of the resolved table with the defaults applied
+func (db *DB) Exporter(tname string) *Exporter {
+	return &Exporter{Scan: *db.Scan(tname)}
+}
+
+//Importer prepares an import into the resolved table with the defaults applied
+func (db *DB) Importer(tname string) *Importer {
+	im := NewImporter(db.TableName(tname))
+	im.Tracer, im.Metrics = db.Tracer, db.Metrics
+	return im
+}
+
+//Statement prepares a PartiQL statement with the defaults applied, table names
+//in the statement are used as is, use TableName to resolve them
+func (db *DB) Statement(stmt string, params ...interface{}) *Statement {
+	inp := NewStatement(stmt, params...)
+	inp.Tracer, inp.Metrics = db.Tracer, db.Metrics
+	inp.Encryption = db.Encryption
+	inp.ConsistentRead = db.ConsistentRead
+	inp.ReturnConsumedCapacity = db.ReturnConsumedCapacity
+	return inp
+}
+
+//BatchStatement prepares an empty batch of PartiQL statements with the
+//defaults applied, see Statement
+func (db *DB) BatchStatement() *BatchStatement {
+	inp := NewBatchStatement()
+	inp.Tracer, inp.Metrics = db.Tracer, db.Metrics
+	inp.Encryption = db.Encryption
+	inp.ReturnConsumedCapacity = db.ReturnConsumedCapacity
+	return inp
+}
+
+//BatchWriteItemWithContext passes a batch write on to the client, such that
+//imports can execute against the DB
+func (db *DB) BatchWriteItemWithContext(ctx aws.Context, in *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	c, ok := db.Client.(BatchWriteClient)
+	if !ok {
+		return nil, errNotImplemented(db.Client, "BatchWriteItemWithContext")
+	}
+
+	return c.BatchWriteItemWithContext(ctx, in, opts...)
+}
+
+//ExecuteStatementWithContext passes a PartiQL statement on to the client
+func (db *DB) ExecuteStatementWithContext(ctx aws.Context, in *dynamodb.ExecuteStatementInput, opts ...request.Option) (*dynamodb.ExecuteStatementOutput, error) {
+	c, ok := db.Client.(StatementClient)
+	if !ok {
+		return nil, errNotImplemented(db.Client, "ExecuteStatementWithContext")
+	}
+
+	return c.ExecuteStatementWithContext(ctx, in, opts...)
+}
+
+//BatchExecuteStatementWithContext passes a batch of PartiQL statements on to
+//the client
+func (db *DB) BatchExecuteStatementWithContext(ctx aws.Context, in *dynamodb.BatchExecuteStatementInput, opts ...request.Option) (*dynamodb.BatchExecuteStatementOutput, error) {
+	c, ok := db.Client.(StatementClient)
+	if !ok {
+		return nil, errNotImplemented(db.Client, "BatchExecuteStatementWithContext")
+	}
+
+	return c.BatchExecuteStatementWithContext(ctx, in, opts...)
+}
+
+//TransactGetItemsWithContext passes a transactional read on to the client,
+//such that chunked items can be read through the DB
+func (db *DB) TransactGetItemsWithContext(ctx aws.Context, in *dynamodb.TransactGetItemsInput, opts ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+	c, ok := db.Client.(TransactClient)
+	if !ok {
+		return nil, errNotImplemented(db.Client, "TransactGetItemsWithContext")
+	}
+
+	return c.TransactGetItemsWithContext(ctx, in, opts...)
+}
+
+//TransactWriteItemsWithContext passes a transactional write on to the client,
+//such that chunked items can be written through the DB
+func (db *DB) TransactWriteItemsWithContext(ctx aws.Context, in *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	c, ok := db.Client.(TransactClient)
+	if !ok {
+		return nil, errNotImplemented(db.Client, "TransactWriteItemsWithContext")
+	}
+
+	return c.TransactWriteItemsWithContext(ctx, in, opts...)
+}
+
+//errNotImplemented is returned when the client doesn't implement an operation
+func errNotImplemented(c Client, op string) error {
+	return fmt.Errorf("client %T doesn't implement %s", c, op)
+}
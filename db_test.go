@@ -0,0 +1,93 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// countingClient counts the get requests that pass through it
+type countingClient struct {
+	Client
+	gets int
+}
+
+func (c *countingClient) GetItemWithContext(ctx aws.Context, in *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	c.gets++
+	return c.Client.GetItemWithContext(ctx, in, opts...)
+}
+
+func TestDBDefaults(t *testing.T) {
+	var got *dynamodb.GetItemInput
+	fake := &fakeDB{getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		got = in
+		return &dynamodb.GetItemOutput{Item: item("Name", "a")}, nil
+	}}
+
+	counter := &countingClient{}
+	db := NewDB(fake)
+	db.SetTablePrefix("dev-")
+	db.SetConsistentRead(true)
+	db.SetReturnConsumedCapacity(dynamodb.ReturnConsumedCapacityTotal)
+	db.Use(func(next Client) Client {
+		counter.Client = next
+		return counter
+	})
+
+	it := &named{}
+	ok(t, db.Get("scores", named{"a"}).Execute(db, it))
+	equals(t, "a", it.Name)
+	equals(t, 1, counter.gets)
+	equals(t, "dev-scores", aws.StringValue(got.TableName))
+	equals(t, true, aws.BoolValue(got.ConsistentRead))
+	equals(t, dynamodb.ReturnConsumedCapacityTotal, aws.StringValue(got.ReturnConsumedCapacity))
+
+	db.SetTableNameResolver(func(name string) string { return strings.ToUpper(name) })
+	equals(t, "SCORES", aws.StringValue(db.Query("scores", "Name = :name").TableName))
+}
+
+func TestDBImport(t *testing.T) {
+	var tables []string
+	fake := &fakeDB{batchWrite: func(in *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+		for name := range in.RequestItems {
+			tables = append(tables, name)
+		}
+
+		return &dynamodb.BatchWriteItemOutput{}, nil
+	}}
+
+	db := NewDB(fake)
+	db.SetTablePrefix("dev-")
+	n, err := db.Importer("scores").Execute(aws.BackgroundContext(), db, strings.NewReader(`{"Name":{"S":"a"}}`+"\n"))
+	ok(t, err)
+	equals(t, int64(1), n)
+	equals(t, []string{"dev-scores"}, tables)
+
+	db.Use(func(next Client) Client { return &countingClient{Client: next} })
+	_, err = db.Importer("scores").Execute(aws.BackgroundContext(), db, strings.NewReader(`{"Name":{"S":"a"}}`+"\n"))
+	assert(t, err != nil && strings.Contains(err.Error(), "client *dynamo.countingClient doesn't implement BatchWriteItemWithContext"),
+		"expected unimplemented error, got: %v", err)
+}
+
+func TestDBStatement(t *testing.T) {
+	var got *dynamodb.ExecuteStatementInput
+	fake := &fakeDB{statement: func(in *dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error) {
+		got = in
+		return &dynamodb.ExecuteStatementOutput{Items: []map[string]*dynamodb.AttributeValue{item("Name", "a")}}, nil
+	}}
+
+	db := NewDB(fake)
+	db.SetConsistentRead(true)
+	var list []named
+	_, err := db.Statement(`SELECT * FROM "scores" WHERE Name = ?`, "a").Execute(db, &list)
+	ok(t, err)
+	equals(t, []named{{"a"}}, list)
+	equals(t, true, aws.BoolValue(got.ConsistentRead))
+
+	db.Use(func(next Client) Client { return &countingClient{Client: next} })
+	_, err = db.Statement(`SELECT * FROM "scores"`).Execute(db, &list)
+	assert(t, err != nil && strings.Contains(err.Error(), "doesn't implement ExecuteStatementWithContext"), "expected unimplemented error, got: %v", err)
+}
@@ -5,15 +5,17 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 //Delete holds configuration for a delete
 type Delete struct {
 	ConditionInput
 	ExpressionHolder
+	TracingInput
+	MetricsInput
+	ChunkingInput
 	dynamodb.DeleteItemInput
 	PrimaryKey interface{}
 }
@@ -25,30 +27,48 @@ func NewDelete(tname string, pk interface{}) *Delete {
 	}, PrimaryKey: pk}
 }
 
+//Clone returns a copy of the delete that can be configured independently, the
+//primary key value is shared
+func (inp *Delete) Clone() *Delete {
+	c := *inp
+	c.ExpressionHolder = inp.ExpressionHolder.clone()
+	c.DeleteItemInput = *awsutil.CopyOf(&inp.DeleteItemInput).(*dynamodb.DeleteItemInput)
+	return &c
+}
+
 //Execute will delete an item with the background context
-func (inp *Delete) Execute(db dynamodbiface.DynamoDBAPI) (err error) {
+func (inp *Delete) Execute(db Client) (err error) {
 	return inp.ExecuteWithContext(aws.BackgroundContext(), db)
 }
 
 // ExecuteWithContext will delete an item from by its primary key
-func (inp *Delete) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDBAPI) (err error) {
-	ipk, err := dynamodbattribute.MarshalMap(inp.PrimaryKey)
+func (inp *Delete) ExecuteWithContext(ctx aws.Context, db Client) (err error) {
+	c := startCall(ctx, inp.Tracer, inp.Metrics, "DeleteItem", inp.TableName, nil)
+	defer func() { c.end(err) }()
+
+	names, values, err := inp.expressionAttributes(inp.ConditionExpression)
 	if err != nil {
-		return fmt.Errorf("failed to marshal primarky key: %+v", err)
+		return err
 	}
 
-	inp.SetKey(ipk)
-	if len(inp.ExpAttrNames) > 0 {
-		inp.SetExpressionAttributeNames(aws.StringMap(inp.ExpAttrNames))
+	ipk, err := marshalKey(inp.PrimaryKey)
+	if err != nil {
+		return fmt.Errorf("failed to marshal primarky key: %+v", err)
 	}
 
-	if len(inp.ExpAttrValues) > 0 {
-		if inp.ExpressionAttributeValues, err = dynamodbattribute.MarshalMap(inp.ExpAttrValues); err != nil {
-			return fmt.Errorf("failed to marshal expression values: %+v", err)
-		}
+	in := inp.DeleteItemInput
+	in.SetKey(ipk)
+	in.ExpressionAttributeNames, in.ExpressionAttributeValues = names, values
+
+	var out *dynamodb.DeleteItemOutput
+	if inp.Chunking != nil {
+		out, err = inp.Chunking.delete(c.ctx, db, &in)
+	} else {
+		out, err = db.DeleteItemWithContext(c.ctx, &in)
 	}
 
-	if _, err = db.DeleteItemWithContext(ctx, &inp.DeleteItemInput); err != nil {
+	if err != nil {
+		c.fail(err)
 		aerr, ok := err.(awserr.Error)
 		if !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
 			return fmt.Errorf("failed to perform request: %+v", err)
@@ -60,5 +80,7 @@ func (inp *Delete) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDB
 		return err
 	}
 
+	c.items(1)
+	c.capacity(out.ConsumedCapacity)
 	return nil
 }
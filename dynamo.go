@@ -8,6 +8,9 @@ import (
 type ExpressionHolder struct {
 	ExpAttrNames  map[string]string
 	ExpAttrValues map[string]interface{}
+
+	//prepared is set on builders that are bound from a template
+	prepared *preparedExpressions
 }
 
 //AddExpressionName adds an dynamo expression name
@@ -40,8 +43,63 @@ func (ci *ConditionInput) SetConditionError(err error) { ci.ConditionError = err
 
 //PagingInput is used when paging can be configured
 type PagingInput struct {
-	MaxPages int
+	MaxPages      int
+	MaxItems      int
+	KeyAttributes []string
+	RateLimiter   RateLimiter
+
+	//resume is set when the caller needs the key to resume from, only then
+	//is it determined for a truncated page
+	resume bool
 }
 
-//SetMaxPages limits the number of pages returned
+//SetMaxPages limits the number of pages returned, AllPages (or any negative
+//number) fetches all of them. Only the first page is fetched by default,
+//unless MaxItems is set.
 func (pi *PagingInput) SetMaxPages(n int) { pi.MaxPages = n }
+
+//SetMaxItems keeps fetching pages until n items that pass the filter are
+//collected, the last page is truncated such that exactly n items are returned.
+//Pages are fetched until the results are exhausted unless MaxPages is set.
+func (pi *PagingInput) SetMaxItems(n int) { pi.MaxItems = n }
+
+//SetKeyAttributes configures the names of the key attributes of the table
+//(and index) that is read. ExecutePage uses them to create the key to resume
+//from when the last page is truncated by MaxItems, if not configured they are
+//derived from the index, the exclusive start key or the registered entity that
+//items decode into. ExecutePage fails before reading when none of these apply.
+func (pi *PagingInput) SetKeyAttributes(names ...string) { pi.KeyAttributes = names }
+
+//SetRateLimiter paces the requests for pages by the read capacity they consume,
+//the consumed capacity is requested automatically when a limiter is configured
+func (pi *PagingInput) SetRateLimiter(l RateLimiter) { pi.RateLimiter = l }
+
+//clone copies the placeholders such that they can be changed independently,
+//the values themselves are shared
+func (eh ExpressionHolder) clone() ExpressionHolder {
+	c := ExpressionHolder{prepared: eh.prepared}
+	if eh.ExpAttrNames != nil {
+		c.ExpAttrNames = make(map[string]string, len(eh.ExpAttrNames))
+		for ph, name := range eh.ExpAttrNames {
+			c.ExpAttrNames[ph] = name
+		}
+	}
+
+	if eh.ExpAttrValues != nil {
+		c.ExpAttrValues = make(map[string]interface{}, len(eh.ExpAttrValues))
+		for ph, val := range eh.ExpAttrValues {
+			c.ExpAttrValues[ph] = val
+		}
+	}
+
+	return c
+}
+
+//clone copies the paging configuration, the rate limiter is shared
+func (pi PagingInput) clone() PagingInput {
+	if pi.KeyAttributes != nil {
+		pi.KeyAttributes = append([]string(nil), pi.KeyAttributes...)
+	}
+
+	return pi
+}
@@ -0,0 +1,342 @@
+//Package dynamotest helps testing code that uses the dynamo builders without
+//a real table, it provides an API wrapper that records the requests and
+//responses to a golden file together with a replayer that serves them back.
+package dynamotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/advanderveer/go-dynamo/codec"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var (
+	attributeValueType = reflect.TypeOf(&dynamodb.AttributeValue{})
+	timeType           = reflect.TypeOf(time.Time{})
+)
+
+//Golden holds the interactions with DynamoDB in the order they happened,
+//inputs and outputs are stored in the JSON format of the DynamoDB API
+type Golden struct {
+	Meta         map[string]string `json:",omitempty"`
+	Interactions []Interaction
+}
+
+//Interaction is a single request together with its response
+type Interaction struct {
+	Operation string
+	Input     json.RawMessage
+	Output    json.RawMessage `json:",omitempty"`
+	Error     *Error          `json:",omitempty"`
+}
+
+//Error is an error that was returned instead of an output
+type Error struct {
+	Code       string `json:",omitempty"`
+	Message    string
+	StatusCode int `json:",omitempty"`
+}
+
+//LoadGolden reads a golden file
+func LoadGolden(path string) (*Golden, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golden file: %+v", err)
+	}
+
+	g := &Golden{}
+	if err = json.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("failed to decode golden file '%s': %+v", path, err)
+	}
+
+	//the inputs and outputs were indented when saved
+	for i, it := range g.Interactions {
+		g.Interactions[i].Input = compactJSON(it.Input)
+		g.Interactions[i].Output = compactJSON(it.Output)
+	}
+
+	return g, nil
+}
+
+//Save writes the golden file, directories are created as needed
+func (g *Golden) Save(path string) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode golden file: %+v", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create golden file directory: %+v", err)
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+//encodeAPI encodes an SDK input or output in the JSON format of the API,
+//attribute values are encoded by the codec package
+func encodeAPI(v interface{}) (json.RawMessage, error) {
+	ev, err := apiValue(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(ev)
+}
+
+//decodeAPI decodes an SDK output from the JSON format of the API
+func decodeAPI(data json.RawMessage, v interface{}) error {
+	return setAPIValue(data, reflect.ValueOf(v))
+}
+
+//apiName returns the name of a field of an SDK shape in the API, it is empty
+//for fields that are not part of the API
+func apiName(f reflect.StructField) string {
+	if f.PkgPath != "" || f.Name == "_" || f.Tag.Get("json") == "-" {
+		return ""
+	}
+
+	if name := f.Tag.Get("locationName"); name != "" {
+		return name
+	}
+
+	return strings.TrimSuffix(f.Name, "_")
+}
+
+//apiValue converts a value of an SDK shape into a value that encoding/json
+//encodes in the JSON format of the API, nil values are omitted
+func apiValue(v reflect.Value) (interface{}, error) {
+	if v.Type() == attributeValueType {
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		data, err := codec.MarshalValue(v.Interface().(*dynamodb.AttributeValue))
+		return json.RawMessage(data), err
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		return apiValue(v.Elem())
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return float64(v.Interface().(time.Time).UnixNano()) / float64(time.Second), nil
+		}
+
+		m := map[string]interface{}{}
+		for i := 0; i < v.NumField(); i++ {
+			name := apiName(v.Type().Field(i))
+			if name == "" {
+				continue
+			}
+
+			ev, err := apiValue(v.Field(i))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode '%s': %+v", name, err)
+			}
+
+			if ev != nil {
+				m[name] = ev
+			}
+		}
+
+		return m, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			ev, err := apiValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+
+			l[i] = ev
+		}
+
+		return l, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		m := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			ev, err := apiValue(v.MapIndex(k))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode key '%s': %+v", k.String(), err)
+			}
+
+			m[k.String()] = ev
+		}
+
+		return m, nil
+	}
+
+	return v.Interface(), nil
+}
+
+//setAPIValue decodes data in the JSON format of the API into v, which must be
+//a pointer or settable
+func setAPIValue(data json.RawMessage, v reflect.Value) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	if v.Type() == attributeValueType {
+		av, err := codec.UnmarshalValue(data)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(av))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return setAPIValue(data, v.Elem())
+	case reflect.Struct:
+		if v.Type() == timeType {
+			var secs float64
+			if err := json.Unmarshal(data, &secs); err != nil {
+				return err
+			}
+
+			v.Set(reflect.ValueOf(time.Unix(0, int64(secs*float64(time.Second))).UTC()))
+			return nil
+		}
+
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			name := apiName(v.Type().Field(i))
+			if sub, ok := m[name]; ok && name != "" {
+				if err := setAPIValue(sub, v.Field(i)); err != nil {
+					return fmt.Errorf("failed to decode '%s': %+v", name, err)
+				}
+			}
+		}
+
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return json.Unmarshal(data, v.Addr().Interface())
+		}
+
+		var l []json.RawMessage
+		if err := json.Unmarshal(data, &l); err != nil {
+			return err
+		}
+
+		s := reflect.MakeSlice(v.Type(), len(l), len(l))
+		for i, sub := range l {
+			if err := setAPIValue(sub, s.Index(i)); err != nil {
+				return err
+			}
+		}
+
+		v.Set(s)
+		return nil
+	case reflect.Map:
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+
+		mv := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, sub := range m {
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if err := setAPIValue(sub, ev); err != nil {
+				return fmt.Errorf("failed to decode key '%s': %+v", k, err)
+			}
+
+			mv.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), ev)
+		}
+
+		v.Set(mv)
+		return nil
+	}
+
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+//compactJSON removes insignificant whitespace from a valid JSON document
+func compactJSON(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return data
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if json.Compact(buf, data) != nil {
+		return data
+	}
+
+	return buf.Bytes()
+}
+
+//sameJSON reports whether two JSON documents are semantically equal
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
+
+//encodeError records an error returned by the API
+func encodeError(err error) *Error {
+	e := &Error{Message: err.Error()}
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		e.Code, e.Message = aerr.Code(), aerr.Message()
+	}
+
+	var rerr awserr.RequestFailure
+	if errors.As(err, &rerr) {
+		e.StatusCode = rerr.StatusCode()
+	}
+
+	return e
+}
+
+//decodeError recreates an error that was returned by the API
+func decodeError(e *Error) error {
+	if e.Code == "" {
+		return errors.New(e.Message)
+	}
+
+	err := awserr.New(e.Code, e.Message, nil)
+	if e.StatusCode == 0 {
+		return err
+	}
+
+	return awserr.NewRequestFailure(err, e.StatusCode, "")
+}
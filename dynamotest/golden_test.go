@@ -0,0 +1,107 @@
+package dynamotest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/advanderveer/go-dynamo"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+//table is a trivial in-memory table keyed by the 'Id' attribute
+type table struct {
+	dynamodbiface.DynamoDBAPI
+	items map[string]map[string]*dynamodb.AttributeValue
+}
+
+func (tbl *table) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	id := aws.StringValue(in.Item["Id"].S)
+	if _, ok := tbl.items[id]; ok && in.ConditionExpression != nil {
+		return nil, awserr.NewRequestFailure(awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil), 400, "")
+	}
+
+	tbl.items[id] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (tbl *table) GetItemWithContext(ctx aws.Context, in *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: tbl.items[aws.StringValue(in.Key["Id"].S)]}, nil
+}
+
+type thing struct {
+	Id    string
+	Score int
+	Data  []byte
+}
+
+//scenario executes builders against the api and returns what they read
+func scenario(t *testing.T, api dynamodbiface.DynamoDBAPI) (got []thing) {
+	for _, score := range []int{1, 2} {
+		put := dynamo.NewPut("tbl", thing{"a", score, []byte{0, 1}})
+		ok(t, put.Execute(api))
+
+		var th thing
+		ok(t, dynamo.NewGet("tbl", map[string]string{"Id": "a"}).Execute(api, &th))
+		got = append(got, th)
+	}
+
+	put := dynamo.NewPut("tbl", thing{"a", 3, nil})
+	put.SetConditionExpression("attribute_not_exists(Id)")
+	put.SetConditionError(errExists)
+	equals(t, errExists, put.Execute(api))
+	return got
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dynamotest")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	rec := NewRecorder(&table{items: map[string]map[string]*dynamodb.AttributeValue{}})
+	rec.SetMeta("table", "tbl")
+	recorded := scenario(t, rec)
+	equals(t, []thing{{"a", 1, []byte{0, 1}}, {"a", 2, []byte{0, 1}}}, recorded)
+
+	path := filepath.Join(dir, "testdata", "scenario.json")
+	ok(t, rec.Save(path))
+
+	rp, err := LoadReplayer(path)
+	ok(t, err)
+	equals(t, "tbl", rp.Meta("table"))
+	equals(t, recorded, scenario(t, rp))
+	ok(t, rp.Verify())
+
+	//requests that differ from the recording fail, as do missed interactions
+	rp, err = LoadReplayer(path)
+	ok(t, err)
+	err = dynamo.NewPut("tbl", thing{"b", 1, nil}).Execute(rp)
+	assert(t, err != nil && strings.Contains(err.Error(), "unexpected PutItem request"), "expected unexpected request, got: %v", err)
+
+	err = rp.Verify()
+	assert(t, err != nil, "expected verification to fail")
+	equals(t, 6, strings.Count(err.Error(), "\n"))
+}
+
+func TestAPIJSON(t *testing.T) {
+	out := &dynamodb.QueryOutput{
+		Count:            aws.Int64(1),
+		Items:            []map[string]*dynamodb.AttributeValue{{"Id": {S: aws.String("a")}, "Data": {B: []byte{0, 1}}}},
+		LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"Id": {S: aws.String("a")}},
+		ConsumedCapacity: &dynamodb.ConsumedCapacity{TableName: aws.String("tbl"), CapacityUnits: aws.Float64(0.5)},
+	}
+
+	data, err := encodeAPI(out)
+	ok(t, err)
+	equals(t, `{"ConsumedCapacity":{"CapacityUnits":0.5,"TableName":"tbl"},"Count":1,"Items":[{"Data":{"B":"AAE="},"Id":{"S":"a"}}],"LastEvaluatedKey":{"Id":{"S":"a"}}}`, string(data))
+
+	back := &dynamodb.QueryOutput{}
+	ok(t, decodeAPI(data, back))
+	equals(t, out, back)
+}
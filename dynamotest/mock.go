@@ -0,0 +1,442 @@
+package dynamotest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/advanderveer/go-dynamo"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+//Mock implements the item operations of the DynamoDB API by answering calls
+//with the expectations that were declared, e.g.:
+//
+//	m := dynamotest.NewMock()
+//	m.ExpectUpdate("scores").WithKey(pk).WithValue(":TopScore", 120)
+//	//execute the code under test with m
+//	err := m.Verify()
+//
+//Each call is answered by the first expectation (in declaration order) that
+//matches it and has calls left. Calls without a matching expectation fail.
+//Other methods of the API panic. It is safe for concurrent use.
+type Mock struct {
+	dynamodbiface.DynamoDBAPI
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+//NewMock creates a mock without expectations
+func NewMock() *Mock {
+	return &Mock{}
+}
+
+//ExpectGet declares a GetItem call on the table
+func (m *Mock) ExpectGet(table string) *Expectation { return m.expect("GetItem", table) }
+
+//ExpectPut declares a PutItem call on the table
+func (m *Mock) ExpectPut(table string) *Expectation { return m.expect("PutItem", table) }
+
+//ExpectUpdate declares an UpdateItem call on the table
+func (m *Mock) ExpectUpdate(table string) *Expectation { return m.expect("UpdateItem", table) }
+
+//ExpectDelete declares a DeleteItem call on the table
+func (m *Mock) ExpectDelete(table string) *Expectation { return m.expect("DeleteItem", table) }
+
+//ExpectQuery declares a Query call (for a single page) on the table
+func (m *Mock) ExpectQuery(table string) *Expectation { return m.expect("Query", table) }
+
+//ExpectScan declares a Scan call (for a single page) on the table
+func (m *Mock) ExpectScan(table string) *Expectation { return m.expect("Scan", table) }
+
+func (m *Mock) expect(op, table string) *Expectation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := &Expectation{op: op, table: table, times: 1}
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+//Verify returns an error that describes all calls that were not expected
+//and all expectations that were not met, if any
+func (m *Mock) Verify() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	problems := append([]string{}, m.unexpected...)
+	for _, e := range m.expectations {
+		if e.err != nil {
+			problems = append(problems, fmt.Sprintf("invalid expectation %s: %+v", e, e.err))
+		} else if e.times > 0 && e.calls < e.times {
+			problems = append(problems, fmt.Sprintf("expected %s to be called %d time(s), got: %d", e, e.times, e.calls))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("mock expectations were not met:\n  %s", strings.Join(problems, "\n  "))
+}
+
+//call finds the expectation that answers the call
+func (m *Mock) call(op string, in mockCall) (*Expectation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reasons []string
+	for _, e := range m.expectations {
+		if e.op != op || (e.times > 0 && e.calls >= e.times) {
+			continue
+		}
+
+		if err := e.match(in); err != nil {
+			reasons = append(reasons, fmt.Sprintf("%s: %+v", e, err))
+			continue
+		}
+
+		e.calls++
+		return e, e.err
+	}
+
+	msg := fmt.Sprintf("unexpected %s call on table '%s'", op, in.table)
+	if len(reasons) > 0 {
+		msg += ", no expectation matched: " + strings.Join(reasons, "; ")
+	}
+
+	m.unexpected = append(m.unexpected, msg)
+	return nil, fmt.Errorf("%s", msg)
+}
+
+//GetItemWithContext answers with the item of the matching expectation
+func (m *Mock) GetItemWithContext(ctx aws.Context, in *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	e, err := m.call("GetItem", mockCall{
+		table: aws.StringValue(in.TableName), key: in.Key, names: in.ExpressionAttributeNames,
+		exprs: map[string]*string{"projection": in.ProjectionExpression},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.GetItemOutput{Item: e.item}, e.failure
+}
+
+//PutItemWithContext answers with the attributes of the matching expectation
+func (m *Mock) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	e, err := m.call("PutItem", mockCall{
+		table: aws.StringValue(in.TableName), item: in.Item, names: in.ExpressionAttributeNames, values: in.ExpressionAttributeValues,
+		exprs: map[string]*string{"condition": in.ConditionExpression},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.PutItemOutput{Attributes: e.item}, e.failure
+}
+
+//UpdateItemWithContext answers with the attributes of the matching expectation
+func (m *Mock) UpdateItemWithContext(ctx aws.Context, in *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	e, err := m.call("UpdateItem", mockCall{
+		table: aws.StringValue(in.TableName), key: in.Key, names: in.ExpressionAttributeNames, values: in.ExpressionAttributeValues,
+		exprs: map[string]*string{"update": in.UpdateExpression, "condition": in.ConditionExpression},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.UpdateItemOutput{Attributes: e.item}, e.failure
+}
+
+//DeleteItemWithContext answers with the attributes of the matching expectation
+func (m *Mock) DeleteItemWithContext(ctx aws.Context, in *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	e, err := m.call("DeleteItem", mockCall{
+		table: aws.StringValue(in.TableName), key: in.Key, names: in.ExpressionAttributeNames, values: in.ExpressionAttributeValues,
+		exprs: map[string]*string{"condition": in.ConditionExpression},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.DeleteItemOutput{Attributes: e.item}, e.failure
+}
+
+//QueryWithContext answers with the items of the matching expectation
+func (m *Mock) QueryWithContext(ctx aws.Context, in *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	e, err := m.call("Query", mockCall{
+		table: aws.StringValue(in.TableName), index: aws.StringValue(in.IndexName), key: in.ExclusiveStartKey,
+		names: in.ExpressionAttributeNames, values: in.ExpressionAttributeValues,
+		exprs: map[string]*string{"key condition": in.KeyConditionExpression, "filter": in.FilterExpression, "projection": in.ProjectionExpression},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n := aws.Int64(int64(len(e.items)))
+	return &dynamodb.QueryOutput{Items: e.items, Count: n, ScannedCount: n, LastEvaluatedKey: e.next}, e.failure
+}
+
+//ScanWithContext answers with the items of the matching expectation
+func (m *Mock) ScanWithContext(ctx aws.Context, in *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	e, err := m.call("Scan", mockCall{
+		table: aws.StringValue(in.TableName), index: aws.StringValue(in.IndexName), key: in.ExclusiveStartKey,
+		names: in.ExpressionAttributeNames, values: in.ExpressionAttributeValues,
+		exprs: map[string]*string{"filter": in.FilterExpression, "projection": in.ProjectionExpression},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n := aws.Int64(int64(len(e.items)))
+	return &dynamodb.ScanOutput{Items: e.items, Count: n, ScannedCount: n, LastEvaluatedKey: e.next}, e.failure
+}
+
+//mockCall holds the parts of an input that expectations match on, the key of
+//a query or scan is its exclusive start key
+type mockCall struct {
+	table  string
+	index  string
+	key    map[string]*dynamodb.AttributeValue
+	item   map[string]*dynamodb.AttributeValue
+	names  map[string]*string
+	values map[string]*dynamodb.AttributeValue
+	exprs  map[string]*string
+}
+
+//Expectation is a declared call, it is configured with matchers that all
+//have to match the call's input and with what the call returns
+type Expectation struct {
+	op       string
+	table    string
+	times    int
+	calls    int
+	err      error
+	matchers []func(in mockCall) error
+
+	item    map[string]*dynamodb.AttributeValue
+	items   []map[string]*dynamodb.AttributeValue
+	next    map[string]*dynamodb.AttributeValue
+	failure error
+}
+
+func (e *Expectation) String() string { return fmt.Sprintf("%s on table '%s'", e.op, e.table) }
+
+//match checks the input against the table and all matchers
+func (e *Expectation) match(in mockCall) error {
+	if in.table != e.table {
+		return fmt.Errorf("table is '%s'", in.table)
+	}
+
+	for _, m := range e.matchers {
+		if err := m(in); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//setup remembers the first error that occurred while configuring
+func (e *Expectation) setup(err error) *Expectation {
+	if e.err == nil && err != nil {
+		e.err = err
+	}
+
+	return e
+}
+
+func (e *Expectation) with(m func(in mockCall) error) *Expectation {
+	e.matchers = append(e.matchers, m)
+	return e
+}
+
+//WithKey matches the primary key, it is marshalled like the builders do. For
+//queries and scans it matches the exclusive start key.
+func (e *Expectation) WithKey(pk interface{}) *Expectation {
+	key, err := dynamo.MarshalKey(pk)
+	if err != nil {
+		return e.setup(fmt.Errorf("failed to marshal key: %+v", err))
+	}
+
+	return e.with(func(in mockCall) error {
+		if !reflect.DeepEqual(key, in.key) {
+			return fmt.Errorf("key is %v", in.key)
+		}
+
+		return nil
+	})
+}
+
+//WithItem matches the item of a put, it is marshalled like the builders do
+func (e *Expectation) WithItem(item interface{}) *Expectation {
+	m, err := dynamo.MarshalItem(item)
+	if err != nil {
+		return e.setup(fmt.Errorf("failed to marshal item: %+v", err))
+	}
+
+	return e.with(func(in mockCall) error {
+		if !reflect.DeepEqual(m, in.item) {
+			return fmt.Errorf("item is %v", in.item)
+		}
+
+		return nil
+	})
+}
+
+//WithIndex matches the index of a query or scan
+func (e *Expectation) WithIndex(name string) *Expectation {
+	return e.with(func(in mockCall) error {
+		if in.index != name {
+			return fmt.Errorf("index is '%s'", in.index)
+		}
+
+		return nil
+	})
+}
+
+//withExpr matches one of the expressions of the input
+func (e *Expectation) withExpr(kind, expr string) *Expectation {
+	return e.with(func(in mockCall) error {
+		actual, ok := in.exprs[kind]
+		if !ok {
+			return fmt.Errorf("%s has no %s expression", e.op, kind)
+		}
+
+		if aws.StringValue(actual) != expr {
+			return fmt.Errorf("%s expression is '%s'", kind, aws.StringValue(actual))
+		}
+
+		return nil
+	})
+}
+
+//WithCondition matches the condition expression of a put, update or delete
+func (e *Expectation) WithCondition(expr string) *Expectation { return e.withExpr("condition", expr) }
+
+//WithUpdate matches the update expression of an update
+func (e *Expectation) WithUpdate(expr string) *Expectation { return e.withExpr("update", expr) }
+
+//WithKeyCondition matches the key condition expression of a query
+func (e *Expectation) WithKeyCondition(expr string) *Expectation {
+	return e.withExpr("key condition", expr)
+}
+
+//WithFilter matches the filter expression of a query or scan
+func (e *Expectation) WithFilter(expr string) *Expectation { return e.withExpr("filter", expr) }
+
+//WithProjection matches the projection expression
+func (e *Expectation) WithProjection(expr string) *Expectation {
+	return e.withExpr("projection", expr)
+}
+
+//WithName matches the attribute name of an expression placeholder, e.g. "#t"
+func (e *Expectation) WithName(placeholder, name string) *Expectation {
+	placeholder = "#" + strings.TrimLeft(placeholder, "#")
+	return e.with(func(in mockCall) error {
+		actual, ok := in.names[placeholder]
+		if !ok {
+			return fmt.Errorf("name %s is not set", placeholder)
+		}
+
+		if aws.StringValue(actual) != name {
+			return fmt.Errorf("name %s is '%s'", placeholder, aws.StringValue(actual))
+		}
+
+		return nil
+	})
+}
+
+//WithValue matches the value of an expression placeholder, e.g. ":score", it
+//is marshalled like the builders do
+func (e *Expectation) WithValue(placeholder string, v interface{}) *Expectation {
+	placeholder = ":" + strings.TrimLeft(placeholder, ":")
+	av, err := dynamo.MarshalValue(v)
+	if err != nil {
+		return e.setup(fmt.Errorf("failed to marshal value %s: %+v", placeholder, err))
+	}
+
+	return e.with(func(in mockCall) error {
+		actual, ok := in.values[placeholder]
+		if !ok {
+			return fmt.Errorf("value %s is not set", placeholder)
+		}
+
+		if !reflect.DeepEqual(av, actual) {
+			return fmt.Errorf("value %s is %v", placeholder, actual)
+		}
+
+		return nil
+	})
+}
+
+//Times sets how often the call is expected, zero allows any number of calls
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+//Return configures what the call returns: the item of a get, the attributes
+//of a put, update or delete, or a slice of items for a query or scan. Values
+//are marshalled like the builders do and nil returns nothing.
+func (e *Expectation) Return(v interface{}) *Expectation {
+	if v == nil {
+		return e
+	}
+
+	if e.op != "Query" && e.op != "Scan" {
+		m, err := dynamo.MarshalItem(v)
+		if err != nil {
+			return e.setup(fmt.Errorf("failed to marshal returned item: %+v", err))
+		}
+
+		e.item = m
+		return e
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return e.setup(fmt.Errorf("%s must return a slice of items, got: %T", e.op, v))
+	}
+
+	e.items = []map[string]*dynamodb.AttributeValue{}
+	for i := 0; i < rv.Len(); i++ {
+		m, err := dynamo.MarshalItem(rv.Index(i).Interface())
+		if err != nil {
+			return e.setup(fmt.Errorf("failed to marshal returned item %d: %+v", i, err))
+		}
+
+		e.items = append(e.items, m)
+	}
+
+	return e
+}
+
+//ReturnNext configures the last evaluated key of a query or scan page such
+//that the builder requests another page
+func (e *Expectation) ReturnNext(pk interface{}) *Expectation {
+	key, err := dynamo.MarshalKey(pk)
+	if err != nil {
+		return e.setup(fmt.Errorf("failed to marshal next key: %+v", err))
+	}
+
+	e.next = key
+	return e
+}
+
+//ReturnError configures the error that the call returns
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.failure = err
+	return e
+}
+
+//ReturnConditionFailed makes the call fail like DynamoDB does when the
+//condition expression isn't met
+func (e *Expectation) ReturnConditionFailed() *Expectation {
+	return e.ReturnError(awserr.NewRequestFailure(awserr.New(
+		dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil), 400, ""))
+}
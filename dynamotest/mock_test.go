@@ -0,0 +1,72 @@
+package dynamotest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/advanderveer/go-dynamo"
+)
+
+type score struct {
+	GameTitle string
+	UserId    string
+	TopScore  int
+}
+
+func TestMockExpectations(t *testing.T) {
+	pk := map[string]string{"GameTitle": "Alien Adventure", "UserId": "User-5"}
+
+	m := NewMock()
+	m.ExpectUpdate("scores").WithKey(pk).
+		WithUpdate("SET #s = :TopScore").
+		WithName("#s", "TopScore").
+		WithValue(":TopScore", 120)
+	m.ExpectUpdate("scores").WithKey(pk).WithValue(":TopScore", 10).ReturnConditionFailed()
+	m.ExpectGet("scores").WithKey(pk).Return(score{"Alien Adventure", "User-5", 120})
+	m.ExpectQuery("scores").WithKeyCondition("GameTitle = :t").WithValue(":t", "Alien Adventure").
+		Return([]score{{"Alien Adventure", "User-5", 120}, {"Alien Adventure", "User-6", 90}})
+
+	upd := dynamo.NewUpdate("scores", pk)
+	upd.SetUpdateExpression("SET #s = :TopScore")
+	upd.AddExpressionName("#s", "TopScore")
+	upd.AddExpressionValue(":TopScore", 120)
+	ok(t, upd.Execute(m))
+
+	upd = dynamo.NewUpdate("scores", pk)
+	upd.SetUpdateExpression("SET #s = :TopScore")
+	upd.SetConditionExpression("#s < :TopScore")
+	upd.AddExpressionName("#s", "TopScore")
+	upd.AddExpressionValue(":TopScore", 10)
+	upd.SetConditionError(errExists)
+	equals(t, errExists, upd.Execute(m))
+
+	var s score
+	ok(t, dynamo.NewGet("scores", pk).Execute(m, &s))
+	equals(t, 120, s.TopScore)
+
+	var scores []score
+	q := dynamo.NewQuery("scores", "GameTitle = :t")
+	q.AddExpressionValue(":t", "Alien Adventure")
+	n, err := q.Execute(m, &scores)
+	ok(t, err)
+	equals(t, int64(2), n)
+	equals(t, "User-6", scores[1].UserId)
+
+	ok(t, m.Verify())
+}
+
+func TestMockUnmet(t *testing.T) {
+	m := NewMock()
+	m.ExpectPut("scores").WithItem(score{"Alien Adventure", "User-5", 1})
+	m.ExpectDelete("scores").Times(0)
+
+	err := dynamo.NewPut("scores", score{"Alien Adventure", "User-5", 2}).Execute(m)
+	assert(t, err != nil, "expected unexpected call to fail")
+	assert(t, strings.Contains(err.Error(), "no expectation matched"), "expected reason, got: %v", err)
+
+	err = m.Verify()
+	assert(t, err != nil, "expected verify to fail")
+	assert(t, strings.Contains(err.Error(), "unexpected PutItem call on table 'scores'"), "expected unexpected call, got: %v", err)
+	assert(t, strings.Contains(err.Error(), "expected PutItem on table 'scores' to be called 1 time(s), got: 0"), "expected unmet expectation, got: %v", err)
+	assert(t, !strings.Contains(err.Error(), "DeleteItem"), "expected any number of deletes to be fine, got: %v", err)
+}
@@ -0,0 +1,167 @@
+package dynamotest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+//Recorder wraps a DynamoDB API and records the item, batch, transaction and
+//statement operations that are executed with a context (as the builders do).
+//Other methods are passed to the wrapped API without being recorded. It is
+//safe for concurrent use.
+type Recorder struct {
+	dynamodbiface.DynamoDBAPI
+	mu     sync.Mutex
+	golden Golden
+	err    error
+}
+
+//NewRecorder wraps the API
+func NewRecorder(api dynamodbiface.DynamoDBAPI) *Recorder {
+	return &Recorder{DynamoDBAPI: api}
+}
+
+//SetMeta stores a value in the golden file, e.g. the name of the table that
+//was recorded against
+func (r *Recorder) SetMeta(k, v string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.golden.Meta == nil {
+		r.golden.Meta = map[string]string{}
+	}
+
+	r.golden.Meta[k] = v
+}
+
+//Golden returns a copy of what was recorded so far
+func (r *Recorder) Golden() *Golden {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g := &Golden{Interactions: append([]Interaction{}, r.golden.Interactions...)}
+	if r.golden.Meta != nil {
+		g.Meta = map[string]string{}
+		for k, v := range r.golden.Meta {
+			g.Meta[k] = v
+		}
+	}
+
+	return g
+}
+
+//Save writes what was recorded to a golden file, it fails if any of the
+//interactions couldn't be recorded
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	err := r.err
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return r.Golden().Save(path)
+}
+
+//record executes the call and records it as an interaction
+func record[O any](r *Recorder, op string, in interface{}, call func() (*O, error)) (*O, error) {
+	out, err := call()
+
+	it := Interaction{Operation: op}
+	ierr := error(nil)
+	if it.Input, ierr = encodeAPI(in); ierr == nil {
+		if err != nil {
+			it.Error = encodeError(err)
+		} else {
+			it.Output, ierr = encodeAPI(out)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ierr != nil && r.err == nil {
+		r.err = fmt.Errorf("failed to record %s: %+v", op, ierr)
+	}
+
+	r.golden.Interactions = append(r.golden.Interactions, it)
+	return out, err
+}
+
+//GetItemWithContext executes and records the operation
+func (r *Recorder) GetItemWithContext(ctx aws.Context, in *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	return record(r, "GetItem", in, func() (*dynamodb.GetItemOutput, error) { return r.DynamoDBAPI.GetItemWithContext(ctx, in, opts...) })
+}
+
+//PutItemWithContext executes and records the operation
+func (r *Recorder) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	return record(r, "PutItem", in, func() (*dynamodb.PutItemOutput, error) { return r.DynamoDBAPI.PutItemWithContext(ctx, in, opts...) })
+}
+
+//UpdateItemWithContext executes and records the operation
+func (r *Recorder) UpdateItemWithContext(ctx aws.Context, in *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	return record(r, "UpdateItem", in, func() (*dynamodb.UpdateItemOutput, error) {
+		return r.DynamoDBAPI.UpdateItemWithContext(ctx, in, opts...)
+	})
+}
+
+//DeleteItemWithContext executes and records the operation
+func (r *Recorder) DeleteItemWithContext(ctx aws.Context, in *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	return record(r, "DeleteItem", in, func() (*dynamodb.DeleteItemOutput, error) {
+		return r.DynamoDBAPI.DeleteItemWithContext(ctx, in, opts...)
+	})
+}
+
+//QueryWithContext executes and records the operation
+func (r *Recorder) QueryWithContext(ctx aws.Context, in *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	return record(r, "Query", in, func() (*dynamodb.QueryOutput, error) { return r.DynamoDBAPI.QueryWithContext(ctx, in, opts...) })
+}
+
+//ScanWithContext executes and records the operation
+func (r *Recorder) ScanWithContext(ctx aws.Context, in *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	return record(r, "Scan", in, func() (*dynamodb.ScanOutput, error) { return r.DynamoDBAPI.ScanWithContext(ctx, in, opts...) })
+}
+
+//BatchGetItemWithContext executes and records the operation
+func (r *Recorder) BatchGetItemWithContext(ctx aws.Context, in *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	return record(r, "BatchGetItem", in, func() (*dynamodb.BatchGetItemOutput, error) {
+		return r.DynamoDBAPI.BatchGetItemWithContext(ctx, in, opts...)
+	})
+}
+
+//BatchWriteItemWithContext executes and records the operation
+func (r *Recorder) BatchWriteItemWithContext(ctx aws.Context, in *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	return record(r, "BatchWriteItem", in, func() (*dynamodb.BatchWriteItemOutput, error) {
+		return r.DynamoDBAPI.BatchWriteItemWithContext(ctx, in, opts...)
+	})
+}
+
+//TransactGetItemsWithContext executes and records the operation
+func (r *Recorder) TransactGetItemsWithContext(ctx aws.Context, in *dynamodb.TransactGetItemsInput, opts ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+	return record(r, "TransactGetItems", in, func() (*dynamodb.TransactGetItemsOutput, error) {
+		return r.DynamoDBAPI.TransactGetItemsWithContext(ctx, in, opts...)
+	})
+}
+
+//TransactWriteItemsWithContext executes and records the operation
+func (r *Recorder) TransactWriteItemsWithContext(ctx aws.Context, in *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	return record(r, "TransactWriteItems", in, func() (*dynamodb.TransactWriteItemsOutput, error) {
+		return r.DynamoDBAPI.TransactWriteItemsWithContext(ctx, in, opts...)
+	})
+}
+
+//ExecuteStatementWithContext executes and records the operation
+func (r *Recorder) ExecuteStatementWithContext(ctx aws.Context, in *dynamodb.ExecuteStatementInput, opts ...request.Option) (*dynamodb.ExecuteStatementOutput, error) {
+	return record(r, "ExecuteStatement", in, func() (*dynamodb.ExecuteStatementOutput, error) {
+		return r.DynamoDBAPI.ExecuteStatementWithContext(ctx, in, opts...)
+	})
+}
+
+//BatchExecuteStatementWithContext executes and records the operation
+func (r *Recorder) BatchExecuteStatementWithContext(ctx aws.Context, in *dynamodb.BatchExecuteStatementInput, opts ...request.Option) (*dynamodb.BatchExecuteStatementOutput, error) {
+	return record(r, "BatchExecuteStatement", in, func() (*dynamodb.BatchExecuteStatementOutput, error) {
+		return r.DynamoDBAPI.BatchExecuteStatementWithContext(ctx, in, opts...)
+	})
+}
@@ -0,0 +1,169 @@
+package dynamotest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+//Replayer serves the responses of a golden file. Each request is answered by
+//the first unused interaction of the same operation with an equal input, such
+//that requests that are repeated (or executed concurrently) are replayed in
+//the order they were recorded. Requests without such an interaction fail.
+//Only the operations of the Recorder are supported, other methods panic.
+type Replayer struct {
+	dynamodbiface.DynamoDBAPI
+	mu         sync.Mutex
+	golden     *Golden
+	used       []bool
+	unexpected []string
+}
+
+//NewReplayer creates a replayer for the golden interactions
+func NewReplayer(g *Golden) *Replayer {
+	return &Replayer{golden: g, used: make([]bool, len(g.Interactions))}
+}
+
+//LoadReplayer creates a replayer for a golden file
+func LoadReplayer(path string) (*Replayer, error) {
+	g, err := LoadGolden(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReplayer(g), nil
+}
+
+//Meta returns a value that was stored with the golden file
+func (r *Replayer) Meta(k string) string { return r.golden.Meta[k] }
+
+//Verify returns an error that describes all unexpected requests and all
+//interactions that were not replayed, if any
+func (r *Replayer) Verify() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	problems := append([]string{}, r.unexpected...)
+	for i, it := range r.golden.Interactions {
+		if !r.used[i] {
+			problems = append(problems, fmt.Sprintf("interaction %d (%s) was not replayed: %s", i, it.Operation, it.Input))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("replay doesn't match the golden file:\n  %s", strings.Join(problems, "\n  "))
+}
+
+//replay answers the request with the matching interaction
+func replay[O any](r *Replayer, op string, in interface{}) (*O, error) {
+	data, err := encodeAPI(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s input: %+v", op, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := -1
+	for i, it := range r.golden.Interactions {
+		if r.used[i] || it.Operation != op {
+			continue
+		}
+
+		if next < 0 {
+			next = i
+		}
+
+		if !sameJSON(it.Input, data) {
+			continue
+		}
+
+		r.used[i] = true
+		if it.Error != nil {
+			return nil, decodeError(it.Error)
+		}
+
+		out := new(O)
+		if err = decodeAPI(it.Output, out); err != nil {
+			return nil, fmt.Errorf("failed to decode golden %s output: %+v", op, err)
+		}
+
+		return out, nil
+	}
+
+	msg := fmt.Sprintf("unexpected %s request: %s", op, data)
+	if next >= 0 {
+		msg += fmt.Sprintf(", the next %s request in the golden file is: %s", op, r.golden.Interactions[next].Input)
+	}
+
+	r.unexpected = append(r.unexpected, msg)
+	return nil, fmt.Errorf("%s", msg)
+}
+
+//GetItemWithContext replays the operation
+func (r *Replayer) GetItemWithContext(ctx aws.Context, in *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	return replay[dynamodb.GetItemOutput](r, "GetItem", in)
+}
+
+//PutItemWithContext replays the operation
+func (r *Replayer) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	return replay[dynamodb.PutItemOutput](r, "PutItem", in)
+}
+
+//UpdateItemWithContext replays the operation
+func (r *Replayer) UpdateItemWithContext(ctx aws.Context, in *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	return replay[dynamodb.UpdateItemOutput](r, "UpdateItem", in)
+}
+
+//DeleteItemWithContext replays the operation
+func (r *Replayer) DeleteItemWithContext(ctx aws.Context, in *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	return replay[dynamodb.DeleteItemOutput](r, "DeleteItem", in)
+}
+
+//QueryWithContext replays the operation
+func (r *Replayer) QueryWithContext(ctx aws.Context, in *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	return replay[dynamodb.QueryOutput](r, "Query", in)
+}
+
+//ScanWithContext replays the operation
+func (r *Replayer) ScanWithContext(ctx aws.Context, in *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	return replay[dynamodb.ScanOutput](r, "Scan", in)
+}
+
+//BatchGetItemWithContext replays the operation
+func (r *Replayer) BatchGetItemWithContext(ctx aws.Context, in *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	return replay[dynamodb.BatchGetItemOutput](r, "BatchGetItem", in)
+}
+
+//BatchWriteItemWithContext replays the operation
+func (r *Replayer) BatchWriteItemWithContext(ctx aws.Context, in *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	return replay[dynamodb.BatchWriteItemOutput](r, "BatchWriteItem", in)
+}
+
+//TransactGetItemsWithContext replays the operation
+func (r *Replayer) TransactGetItemsWithContext(ctx aws.Context, in *dynamodb.TransactGetItemsInput, opts ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+	return replay[dynamodb.TransactGetItemsOutput](r, "TransactGetItems", in)
+}
+
+//TransactWriteItemsWithContext replays the operation
+func (r *Replayer) TransactWriteItemsWithContext(ctx aws.Context, in *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	return replay[dynamodb.TransactWriteItemsOutput](r, "TransactWriteItems", in)
+}
+
+//ExecuteStatementWithContext replays the operation
+func (r *Replayer) ExecuteStatementWithContext(ctx aws.Context, in *dynamodb.ExecuteStatementInput, opts ...request.Option) (*dynamodb.ExecuteStatementOutput, error) {
+	return replay[dynamodb.ExecuteStatementOutput](r, "ExecuteStatement", in)
+}
+
+//BatchExecuteStatementWithContext replays the operation
+func (r *Replayer) BatchExecuteStatementWithContext(ctx aws.Context, in *dynamodb.BatchExecuteStatementInput, opts ...request.Option) (*dynamodb.BatchExecuteStatementOutput, error) {
+	return replay[dynamodb.BatchExecuteStatementOutput](r, "BatchExecuteStatement", in)
+}
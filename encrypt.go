@@ -0,0 +1,409 @@
+package dynamo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/advanderveer/go-dynamo/codec"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+//encryptionVersion is the first byte of encrypted attributes, it allows the
+//format to evolve
+const encryptionVersion = 1
+
+//KeyProvider provides the data keys that encrypt the attributes of fields
+//tagged with the encrypt option. It is modelled after a key management
+//service: a data key is generated for each encrypted attribute and stored in
+//its encrypted form, together with the ID of the master key that encrypted it.
+type KeyProvider interface {
+	//DataKey returns a new 256-bit data key, the data key encrypted with the
+	//current master key and the ID of that master key
+	DataKey() (key, encrypted []byte, keyID string, err error)
+
+	//DecryptDataKey decrypts a data key that was encrypted with the master key
+	//with the ID
+	DecryptDataKey(keyID string, encrypted []byte) ([]byte, error)
+}
+
+//Encryption configures how fields tagged with the encrypt option are
+//encrypted, e.g.:
+//
+//	type Customer struct {
+//		PK    string
+//		Email string `dynamo:",encrypt"`
+//		Notes string `dynamo:",compress,encrypt"`
+//	}
+//
+//Attributes are encrypted with AES-GCM using a fresh data key, the key
+//attributes of the item and the attribute's name are authenticated along with
+//it such that the ciphertext can't be moved to another item or attribute. Key
+//attributes that change after the item is written make it undecryptable.
+//
+//Only items are encrypted, expression values are not: an update fails if its
+//primary key is a struct with coded fields and its update expression writes
+//any of them (removing them is allowed). Updates with other primary keys are
+//not checked, coded attributes should be written with a put.
+type Encryption struct {
+	Provider KeyProvider
+
+	//KeyAttributes are the names of the key attributes that encrypted
+	//attributes are bound to, those that an item lacks are skipped but at
+	//least one must be present. The primary key attributes of registered
+	//entities are always included. Gets, queries and scans into structs add
+	//them to a projection that includes an encrypted field.
+	KeyAttributes []string
+}
+
+//NewEncryption configures encryption with the key provider that binds the
+//ciphertexts to the key attributes, e.g. "PK" and "SK"
+func NewEncryption(p KeyProvider, keyAttributes ...string) *Encryption {
+	return &Encryption{Provider: p, KeyAttributes: keyAttributes}
+}
+
+//EncryptionInput is used when an execution marshals or decodes items with
+//encrypted fields
+type EncryptionInput struct {
+	Encryption *Encryption
+}
+
+//SetEncryption configures the encryption of the items that are marshalled and
+//decoded, it takes precedence over the default (see SetDefaultEncryption)
+func (ei *EncryptionInput) SetEncryption(e *Encryption) { ei.Encryption = e }
+
+var defaultEncryption = struct {
+	sync.RWMutex
+	e *Encryption
+}{}
+
+//SetDefaultEncryption configures the encryption that is used when none is
+//configured for an execution, e.g. by MarshalItem and UnmarshalItem. It should
+//be called before items are marshalled.
+func SetDefaultEncryption(e *Encryption) {
+	defaultEncryption.Lock()
+	defer defaultEncryption.Unlock()
+	defaultEncryption.e = e
+}
+
+//StaticKeyProvider encrypts data keys with a single master key that is held in
+//memory, it is meant for tests and local development
+type StaticKeyProvider struct {
+	ID  string
+	key cipher.AEAD
+}
+
+//NewStaticKeyProvider creates a key provider from a 256-bit master key
+func NewStaticKeyProvider(id string, key []byte) (*StaticKeyProvider, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got: %d", len(key))
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StaticKeyProvider{ID: id, key: aead}, nil
+}
+
+//DataKey generates a data key and encrypts it with the master key
+func (p *StaticKeyProvider) DataKey() (key, encrypted []byte, keyID string, err error) {
+	key = make([]byte, 32)
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to generate data key: %+v", err)
+	}
+
+	if encrypted, err = seal(p.key, key, []byte(p.ID)); err != nil {
+		return nil, nil, "", err
+	}
+
+	return key, encrypted, p.ID, nil
+}
+
+//DecryptDataKey decrypts a data key that was encrypted with the master key
+func (p *StaticKeyProvider) DecryptDataKey(keyID string, encrypted []byte) ([]byte, error) {
+	if keyID != p.ID {
+		return nil, fmt.Errorf("unknown master key '%s'", keyID)
+	}
+
+	return open(p.key, encrypted, []byte(keyID))
+}
+
+//encryptCoding encrypts a field's data with the configured encryption
+type encryptCoding struct{}
+
+//newEncryptCoding returns the coding of the encrypt option
+func newEncryptCoding(arg string) (fieldCoding, error) {
+	if arg != "" {
+		return nil, fmt.Errorf("encrypt option doesn't take an argument")
+	}
+
+	return encryptCoding{}, nil
+}
+
+//encode encrypts the data, the result consists of the version, the ID of the
+//master key, the encrypted data key and the sealed data
+func (encryptCoding) encode(fc fieldContext, data []byte) ([]byte, error) {
+	e, err := fc.encryption()
+	if err != nil {
+		return nil, err
+	}
+
+	key, encKey, keyID, err := e.Provider.DataKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data key: %+v", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := e.associatedData(fc)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := seal(aead, data, ad)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyID) > 0xffff || len(encKey) > 0xffff {
+		return nil, fmt.Errorf("key ID or encrypted data key is too long")
+	}
+
+	buf := bytes.NewBuffer([]byte{encryptionVersion})
+	binary.Write(buf, binary.BigEndian, uint16(len(keyID)))
+	buf.WriteString(keyID)
+	binary.Write(buf, binary.BigEndian, uint16(len(encKey)))
+	buf.Write(encKey)
+	buf.Write(sealed)
+	return buf.Bytes(), nil
+}
+
+//decode reverses encode
+func (encryptCoding) decode(fc fieldContext, data []byte) ([]byte, error) {
+	e, err := fc.encryption()
+	if err != nil {
+		return nil, err
+	}
+
+	r := bytes.NewReader(data)
+	if v, err := r.ReadByte(); err != nil || v != encryptionVersion {
+		return nil, fmt.Errorf("unsupported encryption format")
+	}
+
+	read := func() ([]byte, error) {
+		var n uint16
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return nil, err
+		}
+
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+
+	keyID, err := read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key ID: %+v", err)
+	}
+
+	encKey, err := read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data key: %+v", err)
+	}
+
+	key, err := e.Provider.DecryptDataKey(string(keyID), encKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key: %+v", err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := e.associatedData(fc)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(aead, data[len(data)-r.Len():], ad)
+}
+
+//associatedData returns the data that is authenticated along with the
+//attribute: its name and the key attributes of the item in a canonical form
+func (e *Encryption) associatedData(fc fieldContext) ([]byte, error) {
+	bound := e.boundKeys(fc.Type)
+	sorted := make([]string, 0, len(bound))
+	for _, name := range bound {
+		if _, ok := fc.Item[name]; ok && name != fc.Attribute {
+			sorted = append(sorted, name)
+		}
+	}
+
+	if len(sorted) == 0 && len(bound) > 0 {
+		return nil, fmt.Errorf("item has none of the key attributes %v to bind the encrypted attribute to, make sure they are set (and projected when reading)", bound)
+	} else if len(sorted) == 0 {
+		return nil, fmt.Errorf("item has no key attribute to bind the encrypted attribute to, configure them with NewEncryption")
+	}
+
+	keys := codec.Item{}
+	for _, name := range sorted {
+		keys[name] = fc.Item[name]
+	}
+
+	ad, err := codec.Marshal(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key attributes: %+v", err)
+	}
+
+	return append([]byte(fc.Attribute+"\x00"), ad...), nil
+}
+
+//boundKeys returns the sorted names of the key attributes that the encrypted
+//attributes of items of type t are bound to
+func (e *Encryption) boundKeys(t reflect.Type) (names []string) {
+	seen := map[string]bool{}
+	for _, name := range e.KeyAttributes {
+		seen[name] = true
+	}
+
+	if ent := DefaultRegistry.lookup(t); ent != nil {
+		for name := range ent.primary {
+			seen[name] = true
+		}
+	}
+
+	for name := range seen {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+//projectBoundKeys adds the key attributes that encrypted fields are bound to
+//to the projection if it includes an encrypted field of the items that are
+//decoded into v (an item or a slice of them), such that the projected fields
+//can be decrypted. The names are returned with the placeholders of the added
+//attributes.
+func projectBoundKeys(proj **string, names map[string]*string, v interface{}, e *Encryption) map[string]*string {
+	t := indirectType(reflect.TypeOf(v))
+	if t != nil && t.Kind() == reflect.Slice {
+		t = indirectType(t.Elem())
+	}
+
+	if *proj == nil || t == nil {
+		return names
+	}
+
+	projected := map[string]bool{}
+	for _, name := range exprAttributes(aws.StringValue(*proj), names) {
+		projected[name] = true
+	}
+
+	encrypted := false
+	for _, f := range codedFields(t) {
+		for _, opt := range f.Coding {
+			encrypted = encrypted || (projected[f.Name] && strings.SplitN(opt, "=", 2)[0] == "encrypt")
+		}
+	}
+
+	if !encrypted {
+		return names
+	}
+
+	e, err := fieldContext{Encryption: e}.encryption()
+	if err != nil {
+		return names
+	}
+
+	var added []string
+	for _, name := range e.boundKeys(t) {
+		if !projected[name] {
+			added = append(added, name)
+		}
+	}
+
+	if len(added) == 0 {
+		return names
+	}
+
+	merged := map[string]*string{}
+	for ph, name := range names {
+		merged[ph] = name
+	}
+
+	expr := aws.StringValue(*proj)
+	for i, name := range added {
+		ph := fmt.Sprintf("#dynamoKey%d", i)
+		merged[ph] = aws.String(name)
+		expr += ", " + ph
+	}
+
+	*proj = aws.String(expr)
+	return merged
+}
+
+//encryption returns the encryption of the execution or the default
+func (fc fieldContext) encryption() (*Encryption, error) {
+	e := fc.Encryption
+	if e == nil {
+		defaultEncryption.RLock()
+		e = defaultEncryption.e
+		defaultEncryption.RUnlock()
+	}
+
+	if e == nil || e.Provider == nil {
+		return nil, fmt.Errorf("no encryption is configured, see SetEncryption")
+	}
+
+	return e, nil
+}
+
+//newAEAD creates an AES-GCM cipher
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %+v", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+//seal encrypts and authenticates data with a random nonce that is prepended
+func seal(aead cipher.AEAD, data, ad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %+v", err)
+	}
+
+	return aead.Seal(nonce, nonce, data, ad), nil
+}
+
+//open reverses seal
+func open(aead cipher.AEAD, sealed, ad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+
+	n := aead.NonceSize()
+	data, err := aead.Open(nil, sealed[:n], sealed[n:], ad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %+v", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,248 @@
+package dynamo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type customer struct {
+	PK    string
+	SK    string
+	Email string `dynamo:",encrypt"`
+	Notes string `dynamo:",compress,encrypt"`
+}
+
+func TestEncryptedFields(t *testing.T) {
+	p, err := NewStaticKeyProvider("k1", bytes.Repeat([]byte{1}, 32))
+	ok(t, err)
+	enc := NewEncryption(p, "PK", "SK")
+
+	c := customer{"c#1", "profile", "a@example.com", strings.Repeat("notes ", 500)}
+	var put map[string]*dynamodb.AttributeValue
+	db := &fakeDB{
+		putItem: func(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			put = in.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: put}, nil
+		},
+		query: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{put}}, nil
+		},
+	}
+
+	d := NewDB(db)
+	d.SetEncryption(enc)
+	ok(t, d.Put("tbl", c).Execute(d))
+	assert(t, put["Email"].B != nil && !bytes.Contains(put["Email"].B, []byte("example")), "expected email to be encrypted, got: %v", put["Email"])
+	assert(t, len(put["Notes"].B) < 200, "expected notes to be compressed before encryption, got: %d bytes", len(put["Notes"].B))
+	equals(t, "c#1", aws.StringValue(put["PK"].S))
+
+	var got customer
+	ok(t, d.Get("tbl", map[string]string{"PK": "c#1", "SK": "profile"}).Execute(d, &got))
+	equals(t, c, got)
+
+	q := d.Query("tbl", "PK = :pk")
+	q.AddExpressionValue(":pk", "c#1")
+	var list []customer
+	_, err = q.Execute(d, &list)
+	ok(t, err)
+	equals(t, []customer{c}, list)
+
+	t.Run("swapped item", func(t *testing.T) {
+		swapped := map[string]*dynamodb.AttributeValue{}
+		for k, v := range put {
+			swapped[k] = v
+		}
+
+		swapped["PK"] = &dynamodb.AttributeValue{S: aws.String("c#2")}
+		err := unmarshalItem(swapped, &customer{}, enc)
+		assert(t, err != nil && strings.Contains(err.Error(), "failed to decrypt"), "expected decryption to fail, got: %v", err)
+	})
+
+	t.Run("swapped attribute", func(t *testing.T) {
+		swapped := map[string]*dynamodb.AttributeValue{}
+		for k, v := range put {
+			swapped[k] = v
+		}
+
+		swapped["Email"] = put["Notes"]
+		err := unmarshalItem(swapped, &customer{}, enc)
+		assert(t, err != nil && strings.Contains(err.Error(), "failed to decrypt"), "expected decryption to fail, got: %v", err)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		other, err := NewStaticKeyProvider("k1", bytes.Repeat([]byte{2}, 32))
+		ok(t, err)
+		err = unmarshalItem(put, &customer{}, NewEncryption(other, "PK", "SK"))
+		assert(t, err != nil && strings.Contains(err.Error(), "failed to decrypt data key"), "expected decryption to fail, got: %v", err)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		SetDefaultEncryption(enc)
+		defer SetDefaultEncryption(nil)
+
+		var got customer
+		ok(t, UnmarshalItem(put, &got))
+		equals(t, c, got)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		_, err := marshalItem(c, nil)
+		equals(t, "failed to encode field 'Email': no encryption is configured, see SetEncryption", err.Error())
+	})
+
+	t.Run("no key attribute", func(t *testing.T) {
+		_, err := marshalItem(struct {
+			Email string `dynamo:",encrypt"`
+		}{"a@example.com"}, enc)
+		equals(t, "failed to encode field 'Email': item has none of the key attributes [PK SK] to bind the encrypted attribute to, make sure they are set (and projected when reading)", err.Error())
+
+		_, err = marshalItem(struct {
+			Email string `dynamo:",encrypt"`
+		}{"a@example.com"}, NewEncryption(enc.Provider))
+		equals(t, "failed to encode field 'Email': item has no key attribute to bind the encrypted attribute to, configure them with NewEncryption", err.Error())
+	})
+}
+
+func TestEncryptedFieldsDecoders(t *testing.T) {
+	p, err := NewStaticKeyProvider("k1", bytes.Repeat([]byte{1}, 32))
+	ok(t, err)
+	enc := NewEncryption(p, "PK", "SK")
+
+	c := customer{"c#1", "profile", "a@example.com", "notes"}
+	m, err := marshalItem(c, enc)
+	ok(t, err)
+
+	tm := NewTypeMap().RegisterValue("SK", "profile", customer{})
+	t.Run("collection", func(t *testing.T) {
+		db := &fakeDB{query: pages(10, m)}
+		q := NewQuery("tbl", "PK = :pk")
+		q.AddExpressionValue(":pk", "c#1")
+		q.SetEncryption(enc)
+
+		coll := NewCollection(tm)
+		_, err := q.Execute(db, coll)
+		ok(t, err)
+
+		var list []customer
+		ok(t, coll.Get(&list))
+		equals(t, []customer{c}, list)
+	})
+
+	t.Run("stream record", func(t *testing.T) {
+		rec := StreamRecord{EventName: StreamInsert, NewImage: m}
+		var got customer
+		err := rec.DecodeNewImage(&got)
+		assert(t, err != nil && strings.Contains(err.Error(), "no encryption is configured"), "expected missing encryption, got: %v", err)
+
+		rec.SetEncryption(enc)
+		ok(t, rec.DecodeNewImage(&got))
+		equals(t, c, got)
+	})
+
+	t.Run("stream handler", func(t *testing.T) {
+		var seen interface{}
+		h := NewStreamHandler(tm).Handle(StreamInsert, customer{}, func(sc StreamChange) error {
+			seen = sc.New
+			return nil
+		})
+
+		h.SetEncryption(enc)
+		ok(t, h.HandleRecords([]StreamRecord{{EventName: StreamInsert, NewImage: m}}))
+		equals(t, &c, seen)
+	})
+}
+
+func TestUpdateEncryptedFields(t *testing.T) {
+	updates := 0
+	db := &fakeDB{updateItem: func(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		updates++
+		return &dynamodb.UpdateItemOutput{}, nil
+	}}
+
+	for _, c := range []struct {
+		expr string
+		err  string
+	}{
+		{"SET Email = :v", "update expression writes coded attribute 'Email', expression values are not compressed or encrypted: write it with a put"},
+		{"SET #n = :v", "update expression writes coded attribute 'Notes', expression values are not compressed or encrypted: write it with a put"},
+		{"REMOVE Email, Notes SET Other = :v", ""},
+	} {
+		upd := NewUpdate("tbl", customer{PK: "c#1", SK: "profile"})
+		upd.SetUpdateExpression(c.expr)
+		upd.AddExpressionValue(":v", "x")
+		if strings.Contains(c.expr, "#n") {
+			upd.AddExpressionName("#n", "Notes")
+		}
+
+		err := upd.Execute(db)
+		if c.err == "" {
+			ok(t, err)
+			continue
+		}
+
+		equals(t, c.err, err.Error())
+	}
+
+	equals(t, 1, updates)
+
+	upd := NewUpdate("tbl", map[string]string{"PK": "c#1", "SK": "profile"})
+	upd.SetUpdateExpression("SET Email = :v")
+	upd.AddExpressionValue(":v", "x")
+	ok(t, upd.Execute(db))
+}
+
+func TestEncryptedFieldsProjection(t *testing.T) {
+	p, err := NewStaticKeyProvider("k1", bytes.Repeat([]byte{1}, 32))
+	ok(t, err)
+	enc := NewEncryption(p, "PK", "SK")
+
+	m, err := marshalItem(customer{"c#1", "profile", "a@example.com", "notes"}, enc)
+	ok(t, err)
+
+	//project returns the attributes of the item that the projection includes
+	project := func(expr *string, names map[string]*string) map[string]*dynamodb.AttributeValue {
+		out := map[string]*dynamodb.AttributeValue{}
+		for _, name := range exprAttributes(aws.StringValue(expr), names) {
+			out[name] = m[name]
+		}
+
+		return out
+	}
+
+	db := &fakeDB{
+		getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: project(in.ProjectionExpression, in.ExpressionAttributeNames)}, nil
+		},
+		query: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			items := []map[string]*dynamodb.AttributeValue{project(in.ProjectionExpression, in.ExpressionAttributeNames)}
+			return &dynamodb.QueryOutput{Items: items}, nil
+		},
+	}
+
+	g := NewGet("tbl", map[string]string{"PK": "c#1", "SK": "profile"})
+	g.SetProjectionExpression("Email")
+	g.SetEncryption(enc)
+	var got customer
+	ok(t, g.Execute(db, &got))
+	equals(t, customer{PK: "c#1", SK: "profile", Email: "a@example.com"}, got)
+
+	q := NewQuery("tbl", "PK = :pk")
+	q.AddExpressionValue(":pk", "c#1")
+	q.SetProjectionExpression("SK, Email")
+	q.SetEncryption(enc)
+	var list []customer
+	_, err = q.Execute(db, &list)
+	ok(t, err)
+	equals(t, []customer{{PK: "c#1", SK: "profile", Email: "a@example.com"}}, list)
+
+	//the type of the items is unknown when they are decoded by a collection
+	err = unmarshalItem(map[string]*dynamodb.AttributeValue{"Email": m["Email"]}, &customer{}, enc)
+	equals(t, "failed to decode field 'Email': item has none of the key attributes [PK SK] to bind the encrypted attribute to, make sure they are set (and projected when reading)", err.Error())
+}
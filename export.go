@@ -0,0 +1,275 @@
+package dynamo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/advanderveer/go-dynamo/codec"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//maxBatchWriteItems is the maximum number of items DynamoDB accepts in a
+//single BatchWriteItem request
+const maxBatchWriteItems = 25
+
+//Checkpoint records the progress of an export or import such that it can be
+//resumed, it can be stored as JSON
+type Checkpoint struct {
+	//Key is the key to resume an export from, it is nil when the export
+	//completed
+	Key map[string]*dynamodb.AttributeValue
+
+	//Lines is the number of lines that were completely written by an export
+	//or completely imported
+	Lines int64
+}
+
+//MarshalJSON encodes the checkpoint with its key in the DynamoDB JSON format
+func (cp Checkpoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(checkpointJSON{cp.Key, cp.Lines})
+}
+
+//UnmarshalJSON decodes a checkpoint that was encoded with MarshalJSON
+func (cp *Checkpoint) UnmarshalJSON(data []byte) error {
+	var raw checkpointJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	cp.Key, cp.Lines = raw.Key, raw.Lines
+	return nil
+}
+
+//checkpointJSON is the JSON representation of a checkpoint
+type checkpointJSON struct {
+	Key   codec.Item `json:",omitempty"`
+	Lines int64
+}
+
+//Export writes all items of a table to w as JSON Lines in the DynamoDB JSON format
+func Export(ctx aws.Context, db Client, tname string, w io.Writer) (n int64, err error) {
+	return NewExporter(tname).Execute(ctx, db, w)
+}
+
+//Exporter holds the configuration of a table export, the embedded scan can
+//be configured to export a filtered subset, an index or a single segment
+type Exporter struct {
+	Scan
+	Format       ItemFormat
+	Checkpoint   *Checkpoint
+	OnCheckpoint func(cp Checkpoint) error
+}
+
+//NewExporter prepares an export of all items in a table
+func NewExporter(tname string) *Exporter {
+	return &Exporter{Scan: *NewScan(tname)}
+}
+
+//SetFormat configures the JSON format of the exported items
+func (ex *Exporter) SetFormat(f ItemFormat) { ex.Format = f }
+
+//SetCheckpoint resumes a previous export from the checkpoint it reported,
+//the output of the resumed export should be appended to that of the previous
+func (ex *Exporter) SetCheckpoint(cp Checkpoint) { ex.Checkpoint = &cp }
+
+//SetOnCheckpoint configures a function that is called after each page is
+//written and flushed, returning an error stops the export
+func (ex *Exporter) SetOnCheckpoint(fn func(cp Checkpoint) error) { ex.OnCheckpoint = fn }
+
+//Execute scans the table page by page and writes each item on its own line, it
+//returns the number of items written
+func (ex *Exporter) Execute(ctx aws.Context, db Client, w io.Writer) (n int64, err error) {
+	scan := ex.Scan
+	scan.MaxPages, scan.MaxItems = 1, 0
+
+	cp := Checkpoint{Key: scan.ExclusiveStartKey}
+	if ex.Checkpoint != nil {
+		if ex.Checkpoint.Key == nil && ex.Checkpoint.Lines > 0 {
+			return 0, nil //the export was completed already
+		}
+
+		cp = *ex.Checkpoint
+	}
+
+	bw := bufio.NewWriter(w)
+	for {
+		var items []map[string]*dynamodb.AttributeValue
+		scan.ExclusiveStartKey = cp.Key
+		_, next, err := scan.ExecutePage(ctx, db, &items)
+		if err != nil {
+			return n, fmt.Errorf("failed to scan page: %+v", err)
+		}
+
+		for _, item := range items {
+			line, err := EncodeItem(ex.Format, item)
+			if err != nil {
+				return n, fmt.Errorf("failed to encode item: %+v", err)
+			}
+
+			bw.Write(line)
+			bw.WriteByte('\n')
+			n++
+		}
+
+		if err = bw.Flush(); err != nil {
+			return n, fmt.Errorf("failed to write items: %+v", err)
+		}
+
+		cp = Checkpoint{Key: next, Lines: cp.Lines + int64(len(items))}
+		if ex.OnCheckpoint != nil {
+			if err = ex.OnCheckpoint(cp); err != nil {
+				return n, fmt.Errorf("failed to checkpoint: %+v", err)
+			}
+		}
+
+		if next == nil {
+			return n, nil
+		}
+	}
+}
+
+//BatchWriteClient is the part of the DynamoDB API that imports write with, it
+//is implemented by dynamodbiface.DynamoDBAPI
+type BatchWriteClient interface {
+	BatchWriteItemWithContext(aws.Context, *dynamodb.BatchWriteItemInput, ...request.Option) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+//Import reads JSON Lines in the DynamoDB JSON format from r and puts each line
+//as an item into the table
+func Import(ctx aws.Context, db BatchWriteClient, tname string, r io.Reader) (n int64, err error) {
+	return NewImporter(tname).Execute(ctx, db, r)
+}
+
+//Importer holds the configuration of a table import
+type Importer struct {
+	TracingInput
+	MetricsInput
+	TableName    string
+	Format       ItemFormat
+	MaxRetries   int
+	Checkpoint   *Checkpoint
+	OnCheckpoint func(cp Checkpoint) error
+
+	backoff time.Duration
+}
+
+//NewImporter prepares an import of items into a table
+func NewImporter(tname string) *Importer {
+	return &Importer{TableName: tname, MaxRetries: 10, backoff: 50 * time.Millisecond}
+}
+
+//SetFormat configures the JSON format of the imported items
+func (im *Importer) SetFormat(f ItemFormat) { im.Format = f }
+
+//SetMaxRetries configures how often unprocessed items of a batch are retried
+//(with exponential backoff) before the import fails
+func (im *Importer) SetMaxRetries(n int) { im.MaxRetries = n }
+
+//SetCheckpoint resumes a previous import of the same input by skipping the
+//lines that were imported already
+func (im *Importer) SetCheckpoint(cp Checkpoint) { im.Checkpoint = &cp }
+
+//SetOnCheckpoint configures a function that is called after each batch was
+//written completely, returning an error stops the import
+func (im *Importer) SetOnCheckpoint(fn func(cp Checkpoint) error) { im.OnCheckpoint = fn }
+
+//Execute reads the items line by line and writes them in batches, empty lines
+//are skipped. It returns the number of items written.
+func (im *Importer) Execute(ctx aws.Context, db BatchWriteClient, r io.Reader) (n int64, err error) {
+	c := startCall(ctx, im.Tracer, im.Metrics, "Import", aws.String(im.TableName), nil)
+	defer func() { c.items(n); c.end(err) }()
+
+	var skip int64
+	if im.Checkpoint != nil {
+		skip = im.Checkpoint.Lines
+	}
+
+	var lines int64
+	var batch []*dynamodb.WriteRequest
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := im.write(c, db, batch); err != nil {
+			return err
+		}
+
+		n += int64(len(batch))
+		batch = nil
+		if im.OnCheckpoint != nil {
+			if err := im.OnCheckpoint(Checkpoint{Lines: lines}); err != nil {
+				return fmt.Errorf("failed to checkpoint: %+v", err)
+			}
+		}
+
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, rerr := br.ReadBytes('\n')
+		if rerr != nil && rerr != io.EOF {
+			return n, fmt.Errorf("failed to read line %d: %+v", lines+1, rerr)
+		}
+
+		if len(line) > 0 {
+			lines++
+		}
+
+		if lines > skip && len(bytes.TrimSpace(line)) > 0 {
+			item, err := DecodeItem(im.Format, line)
+			if err != nil {
+				return n, fmt.Errorf("failed to decode line %d: %+v", lines, err)
+			}
+
+			batch = append(batch, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}})
+			if len(batch) == maxBatchWriteItems {
+				if err = flush(); err != nil {
+					return n, err
+				}
+			}
+		}
+
+		if rerr == io.EOF {
+			return n, flush()
+		}
+	}
+}
+
+//write puts a batch of items, retrying unprocessed items with exponential backoff
+func (im *Importer) write(c *call, db BatchWriteClient, batch []*dynamodb.WriteRequest) error {
+	reqs := map[string][]*dynamodb.WriteRequest{im.TableName: batch}
+	backoff := im.backoff
+	for attempt := 0; ; attempt++ {
+		out, err := db.BatchWriteItemWithContext(c.ctx, &dynamodb.BatchWriteItemInput{RequestItems: reqs})
+		if err != nil {
+			c.fail(err)
+			return fmt.Errorf("failed to write batch: %+v", err)
+		}
+
+		reqs = out.UnprocessedItems
+		if len(reqs[im.TableName]) == 0 {
+			return nil
+		}
+
+		if attempt >= im.MaxRetries {
+			return fmt.Errorf("failed to write %d unprocessed items after %d retries", len(reqs[im.TableName]), attempt)
+		}
+
+		select {
+		case <-c.ctx.Done():
+			c.fail(c.ctx.Err())
+			return c.ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
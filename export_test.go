@@ -0,0 +1,95 @@
+package dynamo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestExportImport(t *testing.T) {
+	var items []map[string]*dynamodb.AttributeValue
+	for i := 0; i < 30; i++ {
+		items = append(items, map[string]*dynamodb.AttributeValue{"Pos": {N: aws.String(fmt.Sprint(i))}})
+	}
+
+	serve := pages(10, items...)
+	db := &fakeDB{scan: func(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+		out, err := serve(&dynamodb.QueryInput{ExclusiveStartKey: in.ExclusiveStartKey})
+		return &dynamodb.ScanOutput{Items: out.Items, Count: out.Count, LastEvaluatedKey: out.LastEvaluatedKey}, err
+	}}
+
+	var cps []Checkpoint
+	ex := NewExporter("tbl")
+	ex.SetOnCheckpoint(func(cp Checkpoint) error {
+		cps = append(cps, cp)
+		if len(cps) == 2 {
+			return fmt.Errorf("interrupted")
+		}
+
+		return nil
+	})
+
+	buf := bytes.NewBuffer(nil)
+	n, err := ex.Execute(aws.BackgroundContext(), db, buf)
+	assert(t, err != nil, "expected interruption")
+	equals(t, int64(20), n)
+	equals(t, int64(20), cps[1].Lines)
+
+	//resume from a checkpoint that went through storage
+	data, err := json.Marshal(cps[1])
+	ok(t, err)
+	var cp Checkpoint
+	ok(t, json.Unmarshal(data, &cp))
+	equals(t, cps[1], cp)
+
+	ex.SetOnCheckpoint(nil)
+	ex.SetCheckpoint(cp)
+	n, err = ex.Execute(aws.BackgroundContext(), db, buf)
+	ok(t, err)
+	equals(t, int64(10), n)
+	equals(t, 30, strings.Count(buf.String(), "\n"))
+
+	var written []map[string]*dynamodb.AttributeValue
+	var batches []int
+	unprocessed := true
+	db.batchWrite = func(in *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+		reqs := in.RequestItems["tbl"]
+		batches = append(batches, len(reqs))
+		out := &dynamodb.BatchWriteItemOutput{}
+		if unprocessed {
+			unprocessed = false
+			out.UnprocessedItems = map[string][]*dynamodb.WriteRequest{"tbl": reqs[20:]}
+			reqs = reqs[:20]
+		}
+
+		for _, req := range reqs {
+			written = append(written, req.PutRequest.Item)
+		}
+
+		return out, nil
+	}
+
+	im := NewImporter("tbl")
+	im.backoff = 0
+	var imcps []Checkpoint
+	im.SetOnCheckpoint(func(cp Checkpoint) error { imcps = append(imcps, cp); return nil })
+	n, err = im.Execute(aws.BackgroundContext(), db, bytes.NewReader(buf.Bytes()))
+	ok(t, err)
+	equals(t, int64(30), n)
+	equals(t, []int{25, 5, 5}, batches)
+	equals(t, items, written)
+	equals(t, []Checkpoint{{Lines: 25}, {Lines: 30}}, imcps)
+
+	//resuming skips the lines that were imported
+	batches = nil
+	im.SetCheckpoint(Checkpoint{Lines: 25})
+	n, err = im.Execute(aws.BackgroundContext(), db, bytes.NewReader(buf.Bytes()))
+	ok(t, err)
+	equals(t, int64(5), n)
+	equals(t, []int{5}, batches)
+}
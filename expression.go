@@ -0,0 +1,303 @@
+package dynamo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//exprKeywords are the reserved words and functions of the expression syntax
+//that are not attribute names
+var exprKeywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "BETWEEN": true, "IN": true,
+	"SET": true, "REMOVE": true, "ADD": true, "DELETE": true,
+	"attribute_exists": true, "attribute_not_exists": true, "attribute_type": true,
+	"begins_with": true, "contains": true, "size": true,
+	"if_not_exists": true, "list_append": true,
+}
+
+//exprToken is an identifier, name placeholder or value placeholder in an expression
+type exprToken struct {
+	Text string
+
+	//Nested is true when the token is a nested element of a document path
+	Nested bool
+}
+
+//scanExpression returns the identifiers and placeholders in an expression, in
+//the order they appear
+func scanExpression(expr string) (toks []exprToken) {
+	isIdent := func(c byte) bool {
+		return c == '_' || c == '#' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+
+	for i := 0; i < len(expr); {
+		if !isIdent(expr[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i++; i < len(expr) && isIdent(expr[i]) && expr[i] != '#' && expr[i] != ':'; i++ {
+		}
+
+		//skip over list indexes such as '[0]' to find the path separator
+		prev := start - 1
+		for prev >= 0 && (expr[prev] == ']' || expr[prev] == '[' || (expr[prev] >= '0' && expr[prev] <= '9')) {
+			prev--
+		}
+
+		text := expr[start:i]
+		if text[0] >= '0' && text[0] <= '9' {
+			continue
+		}
+
+		toks = append(toks, exprToken{Text: text, Nested: prev >= 0 && expr[prev] == '.'})
+	}
+
+	return toks
+}
+
+//exprAttributes returns the (top-level) attribute names that are referenced by
+//an expression, name placeholders are resolved using the provided names
+func exprAttributes(expr string, names map[string]*string) (attrs []string) {
+	for _, tok := range scanExpression(expr) {
+		switch {
+		case tok.Nested, strings.HasPrefix(tok.Text, ":"), exprKeywords[tok.Text], exprKeywords[strings.ToUpper(tok.Text)]:
+			continue
+		case strings.HasPrefix(tok.Text, "#"):
+			if name, ok := names[tok.Text]; ok {
+				attrs = append(attrs, aws.StringValue(name))
+			}
+		default:
+			attrs = append(attrs, tok.Text)
+		}
+	}
+
+	return attrs
+}
+
+//validateExpressions checks that every placeholder that is referenced by the
+//expressions is defined and that every defined placeholder is referenced.
+//DynamoDB rejects both, but only after a round trip and with an error that
+//doesn't tell which placeholder is the offender.
+func (eh *ExpressionHolder) validateExpressions(exprs ...*string) error {
+	used := map[string]bool{}
+	for _, expr := range exprs {
+		for _, tok := range scanExpression(aws.StringValue(expr)) {
+			if strings.HasPrefix(tok.Text, "#") || strings.HasPrefix(tok.Text, ":") {
+				used[tok.Text] = true
+			}
+		}
+	}
+
+	var undefNames, undefValues, unusedNames, unusedValues []string
+	for ph := range used {
+		_, okName := eh.ExpAttrNames[ph]
+		_, okValue := eh.ExpAttrValues[ph]
+		switch {
+		case strings.HasPrefix(ph, "#") && !okName:
+			undefNames = append(undefNames, ph)
+		case strings.HasPrefix(ph, ":") && !okValue:
+			undefValues = append(undefValues, ph)
+		}
+	}
+
+	for ph := range eh.ExpAttrNames {
+		if !used[ph] {
+			unusedNames = append(unusedNames, ph)
+		}
+	}
+
+	for ph := range eh.ExpAttrValues {
+		if !used[ph] {
+			unusedValues = append(unusedValues, ph)
+		}
+	}
+
+	var problems []string
+	for _, p := range []struct {
+		desc string
+		phs  []string
+	}{
+		{"undefined names", undefNames},
+		{"undefined values", undefValues},
+		{"unused names", unusedNames},
+		{"unused values", unusedValues},
+	} {
+		if len(p.phs) > 0 {
+			sort.Strings(p.phs)
+			problems = append(problems, p.desc+": "+strings.Join(p.phs, ", "))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid expression placeholders, %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+//expressionAttributes validates the placeholders of the expressions and
+//converts them for the SDK input, nil is returned for empty placeholders
+func (eh *ExpressionHolder) expressionAttributes(exprs ...*string) (names map[string]*string, values map[string]*dynamodb.AttributeValue, err error) {
+	if p := eh.prepared; p != nil {
+		if p.matches(eh, exprs) {
+			return p.attributes(eh.ExpAttrValues)
+		}
+
+		//the builder was reconfigured after it was bound
+		merged := p.holder.clone()
+		for ph, name := range eh.ExpAttrNames {
+			merged.AddExpressionName(ph, name)
+		}
+
+		for ph, val := range eh.ExpAttrValues {
+			merged.AddExpressionValue(ph, val)
+		}
+
+		merged.prepared = nil
+		eh = &merged
+	}
+
+	if err = eh.validateExpressions(exprs...); err != nil {
+		return nil, nil, err
+	}
+
+	if len(eh.ExpAttrNames) > 0 {
+		names = aws.StringMap(eh.ExpAttrNames)
+	}
+
+	if len(eh.ExpAttrValues) > 0 {
+		if values, err = marshalValues(eh.ExpAttrValues); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal expression values: %+v", err)
+		}
+	}
+
+	return names, values, nil
+}
+
+//preparedExpressions holds the validated and converted placeholders of a
+//template, value placeholders that are not defined are its parameters
+type preparedExpressions struct {
+	exprs  []string
+	holder ExpressionHolder
+	names  map[string]*string
+	values map[string]*dynamodb.AttributeValue
+	params []string
+}
+
+//prepare validates the expressions, value placeholders that are referenced
+//but not defined become parameters
+func (eh *ExpressionHolder) prepare(exprs ...*string) (p *preparedExpressions, err error) {
+	p = &preparedExpressions{holder: eh.clone()}
+	p.holder.prepared = nil
+
+	withParams := eh.clone()
+	withParams.prepared = nil
+	for _, expr := range exprs {
+		p.exprs = append(p.exprs, aws.StringValue(expr))
+		for _, tok := range scanExpression(aws.StringValue(expr)) {
+			if _, ok := withParams.ExpAttrValues[tok.Text]; ok || !strings.HasPrefix(tok.Text, ":") {
+				continue
+			}
+
+			withParams.AddExpressionValue(tok.Text, nil)
+			p.params = append(p.params, tok.Text)
+		}
+	}
+
+	if err = withParams.validateExpressions(exprs...); err != nil {
+		return nil, err
+	}
+
+	if len(eh.ExpAttrNames) > 0 {
+		p.names = aws.StringMap(eh.ExpAttrNames)
+	}
+
+	if len(eh.ExpAttrValues) > 0 {
+		if p.values, err = marshalValues(eh.ExpAttrValues); err != nil {
+			return nil, fmt.Errorf("failed to marshal expression values: %+v", err)
+		}
+	}
+
+	sort.Strings(p.params)
+	return p, nil
+}
+
+//matches reports whether the builder still has the expressions and names it
+//was bound with
+func (p *preparedExpressions) matches(eh *ExpressionHolder, exprs []*string) bool {
+	if len(eh.ExpAttrNames) > 0 || len(exprs) != len(p.exprs) {
+		return false
+	}
+
+	for i, expr := range exprs {
+		if aws.StringValue(expr) != p.exprs[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+//attributes combines the converted placeholders with the parameter values
+func (p *preparedExpressions) attributes(params map[string]interface{}) (names map[string]*string, values map[string]*dynamodb.AttributeValue, err error) {
+	if err = p.check(params); err != nil {
+		return nil, nil, err
+	}
+
+	if len(p.values)+len(params) == 0 {
+		return p.names, nil, nil
+	}
+
+	values = make(map[string]*dynamodb.AttributeValue, len(p.values)+len(params))
+	for ph, av := range p.values {
+		values[ph] = av
+	}
+
+	for ph, val := range params {
+		if values[ph], err = marshalValue(val); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal parameter %s: %+v", ph, err)
+		}
+	}
+
+	return p.names, values, nil
+}
+
+//check returns an error if parameters are missing or unknown
+func (p *preparedExpressions) check(params map[string]interface{}) error {
+	known := map[string]bool{}
+	var missing, unknown []string
+	for _, ph := range p.params {
+		known[ph] = true
+		if _, ok := params[ph]; !ok {
+			missing = append(missing, ph)
+		}
+	}
+
+	for ph := range params {
+		if !known[ph] {
+			unknown = append(unknown, ph)
+		}
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing parameters: "+strings.Join(missing, ", "))
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		problems = append(problems, "unknown parameters: "+strings.Join(unknown, ", "))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid template parameters, %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestValidateExpressions(t *testing.T) {
+	db := &fakeDB{updateItem: func(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		return &dynamodb.UpdateItemOutput{}, nil
+	}}
+
+	upd := NewUpdate("tbl", map[string]string{"Id": "a"})
+	upd.SetUpdateExpression("SET #s = :score, #l.#n = :now")
+	upd.SetConditionExpression("#s < :score")
+	upd.AddExpressionName("#s", "Score")
+	upd.AddExpressionName("#l", "Log")
+	upd.AddExpressionName("#n", "Last")
+	upd.AddExpressionValue(":score", 10)
+	upd.AddExpressionValue(":now", 1)
+	ok(t, upd.Execute(db))
+
+	upd.SetConditionExpression("#x < :max")
+	upd.AddExpressionValue(":min", 0)
+	err := upd.Execute(db)
+	equals(t, "invalid expression placeholders, undefined names: #x; undefined values: :max; unused values: :min", err.Error())
+
+	q := NewQuery("tbl", "Id = :id")
+	q.AddExpressionName("#s", "Score")
+	_, err = q.Execute(db, &[]named{})
+	equals(t, "invalid expression placeholders, undefined values: :id; unused names: #s", err.Error())
+}
@@ -0,0 +1,304 @@
+package dynamo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//structField describes a struct field as it is encoded into an attribute
+type structField struct {
+	Name  string
+	Index []int
+	Type  reflect.Type
+	Opts  []string
+
+	//Coding holds the options of the 'dynamo' tag that encode the
+	//field's attribute as binary data, e.g. "compress"
+	Coding []string
+}
+
+//fieldCache holds the encoded fields of struct types
+var fieldCache sync.Map
+
+//structFields returns the fields of struct type t as they are encoded by the
+//dynamodbattribute package: fields of embedded structs are promoted, unexported
+//fields and fields tagged with "-" are skipped and the tag can rename the field
+func structFields(t reflect.Type) []structField {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	var fields []structField
+	seen := map[string]bool{}
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("dynamodbav")
+			if tag == "-" {
+				continue
+			}
+
+			name, opts := tag, []string(nil)
+			if idx := strings.Index(tag, ","); idx >= 0 {
+				name, opts = tag[:idx], strings.Split(tag[idx+1:], ",")
+			}
+
+			ft := f.Type
+			if f.Anonymous && name == "" {
+				if indirectType(ft).Kind() == reflect.Struct {
+					walk(indirectType(ft), append(append([]int{}, index...), i))
+					continue
+				}
+			}
+
+			if f.PkgPath != "" {
+				continue
+			}
+
+			if name == "" {
+				name = f.Name
+			}
+
+			//shallower fields take precedence over promoted ones
+			if seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			fields = append(fields, structField{
+				Name:   name,
+				Index:  append(append([]int{}, index...), i),
+				Type:   ft,
+				Opts:   opts,
+				Coding: codingOptions(f.Tag.Get("dynamo")),
+			})
+		}
+	}
+
+	walk(t, nil)
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+//codingOptions returns the options of a 'dynamo' tag that encode the field,
+//the part before the first comma is reserved for a name
+func codingOptions(tag string) (opts []string) {
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		name := strings.SplitN(opt, "=", 2)[0]
+		if fieldCodings[name] != nil {
+			opts = append(opts, opt)
+		}
+	}
+
+	return opts
+}
+
+//fieldCoding encodes the data of a field's attribute, e.g. by compressing it,
+//decode must reverse encode
+type fieldCoding interface {
+	encode(fc fieldContext, data []byte) ([]byte, error)
+	decode(fc fieldContext, data []byte) ([]byte, error)
+}
+
+//fieldContext describes the attribute that is encoded or decoded
+type fieldContext struct {
+	//Attribute is the name of the field's attribute
+	Attribute string
+
+	//Item holds the other attributes of the item, with the composite key
+	//attributes of registered entities rendered
+	Item map[string]*dynamodb.AttributeValue
+
+	//Type is the struct type of the item
+	Type reflect.Type
+
+	//Encryption is configured for the execution, nil for the default
+	Encryption *Encryption
+}
+
+//codedPrefix marks the data of coded attributes: a marker byte followed by the
+//version of the format. Binary attributes without it were written before the
+//field was coded and are decoded as is.
+var codedPrefix = []byte{0xdc, 1}
+
+//fieldCodings resolve the options of the 'dynamo' tag to their coding, the
+//argument of the option (after '=') is passed if present
+var fieldCodings = map[string]func(arg string) (fieldCoding, error){
+	"compress": newCompressCoding,
+	"encrypt":  newEncryptCoding,
+}
+
+//codings resolves the coding options of the field in the order they apply
+func (f structField) codings() (cs []fieldCoding, err error) {
+	for _, opt := range f.Coding {
+		kv := strings.SplitN(opt, "=", 2)
+		arg := ""
+		if len(kv) > 1 {
+			arg = kv[1]
+		}
+
+		c, err := fieldCodings[kv[0]](arg)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %+v", f.Name, err)
+		}
+
+		cs = append(cs, c)
+	}
+
+	return cs, nil
+}
+
+//codedFields returns the fields of struct type t that have coding options
+func codedFields(t reflect.Type) (fields []structField) {
+	t = indirectType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for _, f := range structFields(t) {
+		if len(f.Coding) > 0 {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+//encodeFields replaces the attributes of coded fields of struct value v with
+//their encoded data. Strings and byte slices are encoded as is, other values
+//as JSON. Fields without an attribute (e.g. omitted because they're empty) are
+//left alone. Encrypted fields use e, or the default if it is nil.
+func encodeFields(v reflect.Value, m map[string]*dynamodb.AttributeValue, e *Encryption) error {
+	fields := codedFields(v.Type())
+	if len(fields) == 0 {
+		return nil
+	}
+
+	v = indirectValue(v)
+	for _, f := range fields {
+		if av, ok := m[f.Name]; !ok || av.NULL != nil {
+			continue
+		}
+
+		fv, err := v.FieldByIndexErr(f.Index)
+		if err != nil {
+			continue
+		}
+
+		var data []byte
+		switch {
+		case fv.Kind() == reflect.String:
+			data = []byte(fv.String())
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8:
+			data = fv.Bytes()
+		default:
+			if data, err = json.Marshal(fv.Interface()); err != nil {
+				return fmt.Errorf("failed to encode field '%s' as JSON: %+v", f.Name, err)
+			}
+		}
+
+		cs, err := f.codings()
+		if err != nil {
+			return err
+		}
+
+		fc := fieldContext{Attribute: f.Name, Item: m, Type: v.Type(), Encryption: e}
+		for _, c := range cs {
+			if data, err = c.encode(fc, data); err != nil {
+				return fmt.Errorf("failed to encode field '%s': %+v", f.Name, err)
+			}
+		}
+
+		m[f.Name] = &dynamodb.AttributeValue{B: append(append([]byte{}, codedPrefix...), data...)}
+	}
+
+	return nil
+}
+
+//codedAttributes splits the binary attributes of coded fields from the item,
+//the rest of the item can then be decoded as usual. Attributes that are not
+//binary (e.g. written before the field was coded) are left in the item.
+func codedAttributes(m map[string]*dynamodb.AttributeValue, fields []structField) (rest map[string]*dynamodb.AttributeValue, coded map[string][]byte) {
+	for _, f := range fields {
+		if av, ok := m[f.Name]; ok && av.B != nil {
+			if coded == nil {
+				coded = map[string][]byte{}
+			}
+
+			coded[f.Name] = av.B
+		}
+	}
+
+	if len(coded) == 0 {
+		return m, nil
+	}
+
+	rest = make(map[string]*dynamodb.AttributeValue, len(m))
+	for name, av := range m {
+		if _, ok := coded[name]; !ok {
+			rest[name] = av
+		}
+	}
+
+	return rest, coded
+}
+
+//decodeFields decodes the data of coded attributes of item m into the fields
+//of struct value v, embedded struct pointers are allocated as needed. Encrypted
+//fields use e, or the default if it is nil. Data without the coded prefix is
+//raw and only decoded into string and byte slice fields.
+func decodeFields(m map[string]*dynamodb.AttributeValue, coded map[string][]byte, v reflect.Value, fields []structField, e *Encryption) error {
+	v = indirectValue(v)
+	for _, f := range fields {
+		data, ok := coded[f.Name]
+		if !ok {
+			continue
+		}
+
+		fv := fieldByIndex(v, f.Index)
+		if !bytes.HasPrefix(data, codedPrefix) {
+			if !isRaw(fv.Type()) {
+				return fmt.Errorf("failed to decode field '%s': binary attribute is not coded, it was probably written before the field was tagged", f.Name)
+			}
+		} else {
+			cs, err := f.codings()
+			if err != nil {
+				return err
+			}
+
+			data = data[len(codedPrefix):]
+			fc := fieldContext{Attribute: f.Name, Item: m, Type: v.Type(), Encryption: e}
+			for i := len(cs) - 1; i >= 0; i-- {
+				if data, err = cs[i].decode(fc, data); err != nil {
+					return fmt.Errorf("failed to decode field '%s': %+v", f.Name, err)
+				}
+			}
+		}
+
+		switch {
+		case fv.Kind() == reflect.String:
+			fv.SetString(string(data))
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8:
+			fv.SetBytes(data)
+		default:
+			if err := json.Unmarshal(data, fv.Addr().Interface()); err != nil {
+				return fmt.Errorf("failed to decode field '%s' from JSON: %+v", f.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//isRaw returns whether values of type t are coded as is rather than as JSON
+func isRaw(t reflect.Type) bool {
+	return t.Kind() == reflect.String || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+}
@@ -4,14 +4,17 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 //Get holds configuration for getting an item
 type Get struct {
 	ExpressionHolder
+	TracingInput
+	MetricsInput
+	ChunkingInput
+	EncryptionInput
 	dynamodb.GetItemInput
 	ItemNilError error
 	PrimaryKey   interface{}
@@ -27,33 +30,74 @@ func NewGet(tname string, pk interface{}) *Get {
 	}, PrimaryKey: pk}
 }
 
+//Clone returns a copy of the get that can be configured independently, the
+//primary key value is shared
+func (inp *Get) Clone() *Get {
+	c := *inp
+	c.ExpressionHolder = inp.ExpressionHolder.clone()
+	c.GetItemInput = *awsutil.CopyOf(&inp.GetItemInput).(*dynamodb.GetItemInput)
+	return &c
+}
+
 //Execute will get an item with the background context
-func (inp *Get) Execute(db dynamodbiface.DynamoDBAPI, item interface{}) (err error) {
+func (inp *Get) Execute(db Client, item interface{}) (err error) {
 	return inp.ExecuteWithContext(aws.BackgroundContext(), db, item)
 }
 
 // ExecuteWithContext will retrieve a specific item from a DynamoDB table by its primary key
-func (inp *Get) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDBAPI, item interface{}) (err error) {
-	ipk, err := dynamodbattribute.MarshalMap(inp.PrimaryKey)
+func (inp *Get) ExecuteWithContext(ctx aws.Context, db Client, item interface{}) (err error) {
+	c := startCall(ctx, inp.Tracer, inp.Metrics, "GetItem", inp.TableName, nil)
+	defer func() { c.end(err) }()
+
+	names, _, err := inp.expressionAttributes(inp.ProjectionExpression)
+	if err != nil {
+		return err
+	}
+
+	ipk, err := marshalKey(inp.PrimaryKey)
 	if err != nil {
 		return fmt.Errorf("failed to marshal primary key: %+v", err)
 	}
 
-	inp.SetKey(ipk)
-	if len(inp.ExpAttrNames) > 0 {
-		inp.SetExpressionAttributeNames(aws.StringMap(inp.ExpAttrNames))
+	in := inp.GetItemInput
+	in.SetKey(ipk)
+	names = projectBoundKeys(&in.ProjectionExpression, names, item, inp.Encryption)
+	in.ExpressionAttributeNames = names
+	if inp.Chunking != nil && in.ProjectionExpression != nil {
+		//the manifest of a chunked item must be recognized as such
+		in.ProjectionExpression = aws.String(aws.StringValue(in.ProjectionExpression) + ", #dynamoChunks")
+		in.ExpressionAttributeNames = map[string]*string{"#dynamoChunks": aws.String(ChunksAttribute)}
+		for ph, name := range names {
+			in.ExpressionAttributeNames[ph] = name
+		}
 	}
 
 	var out *dynamodb.GetItemOutput
-	if out, err = db.GetItemWithContext(ctx, &inp.GetItemInput); err != nil {
+	if out, err = db.GetItemWithContext(c.ctx, &in); err != nil {
+		c.fail(err)
 		return fmt.Errorf("failed to perform request: %+v", err)
 	}
 
+	c.capacity(out.ConsumedCapacity)
 	if out.Item == nil {
+		c.notFound()
 		return inp.ItemNilError
 	}
 
-	err = dynamodbattribute.UnmarshalMap(out.Item, item)
+	if inp.Chunking != nil {
+		if out.Item, err = inp.Chunking.reassemble(c.ctx, db, in.TableName, out.Item); err != nil {
+			c.fail(err)
+			return fmt.Errorf("failed to reassemble chunked item: %+v", err)
+		}
+
+		if out.Item == nil {
+			c.notFound()
+			return inp.ItemNilError
+		}
+	}
+
+	c.items(1)
+	err = unmarshalItem(out.Item, item, inp.Encryption)
 	if err != nil {
 		return fmt.Errorf("failed to unmarshal item: %+v", err)
 	}
@@ -0,0 +1,159 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//Index describes a secondary index of a table
+type Index struct {
+	Name   string
+	Global bool
+
+	//HashKey and RangeKey are the key attributes of the index
+	HashKey  string
+	RangeKey string
+
+	//TableHashKey and TableRangeKey are the key attributes of the table, they
+	//are always projected into the index
+	TableHashKey  string
+	TableRangeKey string
+
+	//Projection is the projection type of the index (ALL, KEYS_ONLY or
+	//INCLUDE), NonKeyAttributes are projected in addition to the keys for INCLUDE
+	Projection       string
+	NonKeyAttributes []string
+}
+
+//IsKey returns whether the attribute is a key attribute of the index
+func (idx *Index) IsKey(attr string) bool {
+	return attr != "" && (attr == idx.HashKey || attr == idx.RangeKey)
+}
+
+//Projects returns whether the attribute is projected into the index
+func (idx *Index) Projects(attr string) bool {
+	if idx.Projection == dynamodb.ProjectionTypeAll {
+		return true
+	}
+
+	if idx.IsKey(attr) || attr == idx.TableHashKey || attr == idx.TableRangeKey {
+		return true
+	}
+
+	if idx.Projection == dynamodb.ProjectionTypeInclude {
+		for _, nka := range idx.NonKeyAttributes {
+			if nka == attr {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//keyAttributes returns the key attributes of the index and the table
+func (idx *Index) keyAttributes() (names []string) {
+	seen := map[string]bool{}
+	for _, name := range []string{idx.HashKey, idx.RangeKey, idx.TableHashKey, idx.TableRangeKey} {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+//validateQuery checks a query on the index before it is send: the key condition
+//may only reference the index keys, consistent reads are rejected on global
+//indexes and all attributes the query needs must be projected. For global
+//indexes the needed attributes are those of the projection expression or, if
+//there is none, those of the destination struct. Local indexes fetch
+//attributes that are not projected from the table so they are not checked.
+func (idx *Index) validateQuery(in *dynamodb.QueryInput, items interface{}) error {
+	if idx.Global && aws.BoolValue(in.ConsistentRead) {
+		return fmt.Errorf("consistent reads are not supported on global secondary index '%s'", idx.Name)
+	}
+
+	hasHash := false
+	for _, attr := range exprAttributes(aws.StringValue(in.KeyConditionExpression), in.ExpressionAttributeNames) {
+		if !idx.IsKey(attr) {
+			return fmt.Errorf("key condition references '%s' which is not a key of index '%s'", attr, idx.Name)
+		}
+
+		if attr == idx.HashKey {
+			hasHash = true
+		}
+	}
+
+	if !hasHash {
+		return fmt.Errorf("key condition must reference the hash key '%s' of index '%s'", idx.HashKey, idx.Name)
+	}
+
+	if !idx.Global || idx.Projection == dynamodb.ProjectionTypeAll || aws.StringValue(in.Select) == dynamodb.SelectCount {
+		return nil
+	}
+
+	if aws.StringValue(in.Select) == dynamodb.SelectAllAttributes {
+		return fmt.Errorf("all attributes can't be selected from global secondary index '%s' as it doesn't project all attributes", idx.Name)
+	}
+
+	var needed []string
+	if in.ProjectionExpression != nil {
+		needed = exprAttributes(aws.StringValue(in.ProjectionExpression), in.ExpressionAttributeNames)
+	} else {
+		needed = destinationAttributes(items)
+	}
+
+	missing := map[string]bool{}
+	for _, attr := range needed {
+		if !idx.Projects(attr) {
+			missing[attr] = true
+		}
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for attr := range missing {
+			names = append(names, attr)
+		}
+
+		sort.Strings(names)
+		return fmt.Errorf("global secondary index '%s' doesn't project attribute(s) %s, set a projection expression to fetch only the projected attributes", idx.Name, strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+//destinationAttributes returns the attributes of the struct that items are
+//decoded into, if items is a pointer to a slice of structs
+func destinationAttributes(items interface{}) (attrs []string) {
+	if items == nil {
+		return nil
+	}
+
+	if _, ok := items.(ItemsDecoder); ok {
+		return nil
+	}
+
+	t := indirectType(reflect.TypeOf(items))
+	if t.Kind() != reflect.Slice {
+		return nil
+	}
+
+	elem := indirectType(t.Elem())
+	if elem.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for _, f := range structFields(elem) {
+		attrs = append(attrs, f.Name)
+	}
+
+	return attrs
+}
@@ -0,0 +1,75 @@
+package dynamo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+var titleIndex = &Index{
+	Name:          "GameTitleIndex",
+	Global:        true,
+	HashKey:       "GameTitle",
+	RangeKey:      "TopScore",
+	TableHashKey:  "GameTitle",
+	TableRangeKey: "UserId",
+	Projection:    dynamodb.ProjectionTypeKeysOnly,
+}
+
+type score struct {
+	GameTitle string
+	UserID    string `dynamodbav:"UserId"`
+	TopScore  int64
+	Rank      int
+}
+
+func TestQueryOnIndex(t *testing.T) {
+	db := &fakeDB{query: pages(10)}
+	for _, c := range []struct {
+		name  string
+		setup func(q *Query)
+		items interface{}
+		err   string
+	}{
+		{"projected struct", nil, &[]struct{ GameTitle, UserId string }{}, ""},
+		{"struct with unprojected attribute", nil, &[]score{}, "doesn't project attribute(s) Rank"},
+		{"projection expression", func(q *Query) { q.SetProjectionExpression("GameTitle, #ts") }, &[]score{}, ""},
+		{"unprojected projection expression", func(q *Query) { q.SetProjectionExpression("GameTitle, Rank.Nested") }, &[]score{}, "doesn't project attribute(s) Rank"},
+		{"count", func(q *Query) { q.SetSelect(dynamodb.SelectCount) }, nil, ""},
+		{"consistent read", func(q *Query) { q.SetConsistentRead(true) }, nil, "consistent reads are not supported"},
+		{"key condition on table key", func(q *Query) {
+			q.SetKeyConditionExpression("GameTitle = :title AND UserId = :user")
+			q.SetFilterExpression("#ts > :min")
+			q.AddExpressionValue(":user", "User-1")
+		}, nil, "references 'UserId' which is not a key"},
+		{"key condition without hash key", func(q *Query) {
+			q.SetKeyConditionExpression("#ts > :min")
+			q.SetFilterExpression("GameTitle = :title")
+		}, nil, "must reference the hash key 'GameTitle'"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			q := NewQuery("tbl", "GameTitle = :title AND #ts > :min").OnIndex(titleIndex)
+			q.AddExpressionName("#ts", "TopScore")
+			q.AddExpressionValue(":title", "Alien Adventure")
+			q.AddExpressionValue(":min", 100)
+			if c.setup != nil {
+				c.setup(q)
+			}
+
+			_, err := q.Execute(db, c.items)
+			if c.err == "" {
+				ok(t, err)
+				return
+			}
+
+			assert(t, err != nil && strings.Contains(err.Error(), c.err), "expected error containing %q, got: %v", c.err, err)
+		})
+	}
+}
+
+func TestQueryOnNilIndex(t *testing.T) {
+	q := NewQuery("tbl", "GameTitle = :title").OnIndex(titleIndex).OnIndex(nil)
+	equals(t, (*Index)(nil), q.Index)
+	equals(t, (*string)(nil), q.IndexName)
+}
@@ -0,0 +1,139 @@
+package dynamo
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//call is the instrumentation state of a single builder execution, it opens
+//the spans and reports an observation to the metrics sink when it ends
+type call struct {
+	ctx     aws.Context
+	span    Span
+	tracer  Tracer
+	metrics Metrics
+	obs     Observation
+	start   time.Time
+	cause   error
+}
+
+//startCall instruments the execution of an operation on a table (and
+//optionally an index), both the tracer and metrics may be nil
+func startCall(ctx aws.Context, t Tracer, m Metrics, op string, table, index *string) *call {
+	if t == nil {
+		t = NoopTracer{}
+	}
+
+	if m == nil {
+		m = DefaultMetrics
+	}
+
+	c := &call{tracer: t, metrics: m, start: time.Now(), obs: Observation{
+		Operation: op,
+		Table:     aws.StringValue(table),
+		Index:     aws.StringValue(index),
+	}}
+
+	c.ctx, c.span = t.Start(ctx, "dynamo."+op)
+	c.span.SetAttributes(
+		Attribute{AttrOperation, op},
+		Attribute{AttrTable, c.obs.Table},
+	)
+
+	if c.obs.Index != "" {
+		c.span.SetAttributes(Attribute{AttrIndex, c.obs.Index})
+	}
+
+	return c
+}
+
+//startPage opens a child span for the next page of a query or scan
+func (c *call) startPage() (aws.Context, Span) {
+	c.obs.Pages++
+	ctx, span := c.tracer.Start(c.ctx, "dynamo."+c.obs.Operation+".page")
+	span.SetAttributes(Attribute{AttrOperation, c.obs.Operation}, Attribute{AttrPage, c.obs.Pages})
+	return ctx, span
+}
+
+//endPage annotates a page span with the page's result and ends it
+func (c *call) endPage(span Span, pg *page, err error) {
+	if err != nil {
+		class := ErrorClass(err)
+		if class == ErrClassThrottled {
+			c.obs.Throttles++
+		}
+
+		span.SetAttributes(Attribute{AttrErrorClass, class})
+		span.RecordError(err)
+	}
+
+	if pg != nil {
+		span.SetAttributes(
+			Attribute{AttrItems, pg.Count},
+			Attribute{AttrScannedItems, pg.ScannedCount},
+		)
+
+		if pg.ConsumedCapacity != nil {
+			span.SetAttributes(Attribute{AttrConsumedCapacity, aws.Float64Value(pg.ConsumedCapacity.CapacityUnits)})
+		}
+	}
+
+	span.End()
+}
+
+//capacity adds consumed capacity (if any) to the call's total
+func (c *call) capacity(cc *dynamodb.ConsumedCapacity) {
+	if cc == nil {
+		return
+	}
+
+	c.obs.ConsumedCapacity += aws.Float64Value(cc.CapacityUnits)
+	c.span.SetAttributes(Attribute{AttrConsumedCapacity, c.obs.ConsumedCapacity})
+}
+
+//items sets the number of items the call returned or wrote
+func (c *call) items(n int64) { c.obs.Items = n }
+
+//notFound records that a get found no item, which is a normal outcome even if
+//the execution returns an error for it (see SetItemNilError)
+func (c *call) notFound() { c.obs.NotFound = true }
+
+//fail records the sdk error that caused the call to fail
+func (c *call) fail(err error) {
+	c.cause = err
+	if c.obs.Pages == 0 && ErrorClass(err) == ErrClassThrottled {
+		c.obs.Throttles++
+	}
+}
+
+//end annotates the span with the outcome of the call and ends it. Errors are
+//classified by the sdk error that caused them (if any), such that configured
+//errors (e.g. a ConditionError) are still reported with their actual class.
+func (c *call) end(err error) {
+	c.obs.Duration = time.Since(c.start)
+	c.span.SetAttributes(Attribute{AttrItems, c.obs.Items})
+	if c.obs.Pages > 0 {
+		c.span.SetAttributes(Attribute{AttrPages, c.obs.Pages})
+	}
+
+	if c.obs.NotFound {
+		c.span.SetAttributes(Attribute{AttrNotFound, true})
+	}
+
+	if err != nil && !c.obs.NotFound {
+		c.obs.ErrorClass = ErrClassClient
+		if c.cause != nil {
+			c.obs.ErrorClass = ErrorClass(c.cause)
+		}
+
+		c.span.SetAttributes(Attribute{AttrErrorClass, c.obs.ErrorClass})
+		c.span.RecordError(err)
+	}
+
+	c.span.End()
+	if c.metrics != nil {
+		c.metrics.Observe(c.obs)
+	}
+}
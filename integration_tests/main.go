@@ -1,6 +1,11 @@
 package main
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/advanderveer/go-dynamo"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
 
 var (
 	//ErrGameScoreExists is returned when we expect a score not to exist
@@ -10,6 +15,18 @@ var (
 	ErrGameScoreNotExists = errors.New("game score doesn't exist")
 )
 
+//GameTitleIndex is the global secondary index of game scores by title
+var GameTitleIndex = &dynamo.Index{
+	Name:             "GameTitleIndex",
+	Global:           true,
+	HashKey:          "GameTitle",
+	RangeKey:         "TopScore",
+	TableHashKey:     "GameTitle",
+	TableRangeKey:    "UserId",
+	Projection:       dynamodb.ProjectionTypeInclude,
+	NonKeyAttributes: []string{"UserId"},
+}
+
 //GameScorePK is the primary key of a game score
 type GameScorePK struct {
 	GameTitle string `dynamodbav:"GameTitle"`
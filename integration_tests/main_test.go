@@ -5,13 +5,11 @@ import (
 	"testing"
 
 	"github.com/advanderveer/go-dynamo"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/aws"
 )
 
 func TestPutGetUpdateDelete(t *testing.T) {
-	sess := newsess(t)
-	tname := tablename(t)
-	db := dynamodb.New(sess)
+	db, tname := newdb(t)
 
 	pk1 := GameScorePK{"Alien Adventure", "User-5"}
 	score1 := &GameScore{pk1, 100}
@@ -146,9 +144,7 @@ func TestPutGetUpdateDelete(t *testing.T) {
 }
 
 func TestQueryScan(t *testing.T) {
-	sess := newsess(t)
-	tname := tablename(t)
-	db := dynamodb.New(sess)
+	db, tname := newdb(t)
 
 	score1 := &GameScore{GameScorePK{"Alien Adventure", "User-1"}, 20}
 	ok(t, dynamo.NewPut(tname, score1).Execute(db))
@@ -229,6 +225,33 @@ func TestQueryScan(t *testing.T) {
 			equals(t, int64(0), list[0].TopScore)
 		})
 
+		t.Run("query max items filtered projection in base table", func(t *testing.T) {
+			list := []*GameScore{}
+
+			q := dynamo.NewQuery(tname, "GameTitle = :GameTitle")
+			q.SetProjectionExpression("GameTitle, UserId")
+			q.SetFilterExpression("#ts > :minTopScore")
+			q.AddExpressionName("#ts", "TopScore")
+			q.AddExpressionValue(":GameTitle", "Alien Adventure")
+			q.AddExpressionValue(":minTopScore", 10)
+			q.SetMaxItems(1)
+			q.SetLimit(2)
+
+			n, next, err := q.ExecutePage(aws.BackgroundContext(), db, &list)
+			ok(t, err)
+			equals(t, int64(1), n)
+			equals(t, "User-1", list[0].UserID)
+			equals(t, "User-1", aws.StringValue(next["UserId"].S))
+
+			q.SetExclusiveStartKey(next)
+			q.SetMaxItems(2)
+			n, next, err = q.ExecutePage(aws.BackgroundContext(), db, &list)
+			ok(t, err)
+			equals(t, int64(2), n)
+			equals(t, "User-2", list[0].UserID)
+			equals(t, "User-3", list[1].UserID)
+		})
+
 		t.Run("query page filtered projection on index", func(t *testing.T) {
 			list := []*GameScore{}
 
@@ -236,7 +259,7 @@ func TestQueryScan(t *testing.T) {
 				"GameTitle = :GameTitle AND TopScore > :minTopScore")
 
 			q.SetProjectionExpression("GameTitle, TopScore")
-			q.SetIndexName("GameTitleIndex")
+			q.OnIndex(GameTitleIndex)
 			q.AddExpressionValue(":GameTitle", "Alien Adventure")
 			q.AddExpressionValue(":minTopScore", 20)
 			q.SetMaxPages(1)
@@ -250,11 +273,26 @@ func TestQueryScan(t *testing.T) {
 			equals(t, "", list[0].UserID)
 		})
 
+		t.Run("consistent query on global index", func(t *testing.T) {
+			list := []*GameScore{}
+
+			q := dynamo.NewQuery(tname,
+				"GameTitle = :GameTitle AND TopScore > :minTopScore")
+
+			q.OnIndex(GameTitleIndex)
+			q.AddExpressionValue(":GameTitle", "Alien Adventure")
+			q.AddExpressionValue(":minTopScore", 20)
+			q.SetConsistentRead(true)
+
+			_, err := q.Execute(db, &list)
+			assert(t, strings.Contains(err.Error(), "consistent reads are not supported"), "expected consistent read error, got: %+v", err)
+		})
+
 		t.Run("count page filtered on index", func(t *testing.T) {
 			q := dynamo.NewQuery(tname,
 				"GameTitle = :GameTitle AND TopScore > :minTopScore")
 
-			q.SetIndexName("GameTitleIndex")
+			q.OnIndex(GameTitleIndex)
 			q.AddExpressionValue(":GameTitle", "Alien Adventure")
 			q.AddExpressionValue(":minTopScore", 20)
 			q.SetMaxPages(1)
@@ -265,6 +303,19 @@ func TestQueryScan(t *testing.T) {
 			ok(t, err)
 			equals(t, int64(2), n)
 		})
+
+		t.Run("count all pages filtered in base table", func(t *testing.T) {
+			q := dynamo.NewQuery(tname, "GameTitle = :GameTitle")
+			q.SetFilterExpression("TopScore > :minTopScore")
+			q.AddExpressionValue(":GameTitle", "Alien Adventure")
+			q.AddExpressionValue(":minTopScore", 20)
+			q.SetLimit(1)
+
+			n, scanned, err := q.Count(aws.BackgroundContext(), db)
+			ok(t, err)
+			equals(t, int64(2), n)
+			equals(t, int64(3), scanned)
+		})
 	})
 
 	t.Run("Scan", func(t *testing.T) {
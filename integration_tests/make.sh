@@ -17,6 +17,17 @@ function run_test { #test our scheduling reactor
 	export $(terraform output env | tr -d ' '); go test -v
 }
 
+function run_record { #test against the table and record golden files
+	echo "--> recording..."
+	export $(cat secrets.env)
+	export $(terraform output env | tr -d ' '); DYNAMO_RECORD=1 go test -v
+}
+
+function run_replay { #test offline by replaying the golden files
+	echo "--> replaying..."
+	DYNAMO_REPLAY=1 go test -v
+}
+
 function run_install { #install go dependencies
 	command -v glide >/dev/null 2>&1 || { echo "executable 'glide' (dependency manager) must be installed: https://github.com/Masterminds/glide" >&2; exit 1; }
 
@@ -47,6 +58,8 @@ function run_destroy { #destroy deployed infrastructure
 
 case $1 in
 	"test") run_test ;;
+	"record") run_record ;;
+	"replay") run_replay ;;
 	"install") run_install ;;
 	"deploy") run_deploy ;;
 	"destroy") run_destroy ;;
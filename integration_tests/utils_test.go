@@ -8,9 +8,12 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/advanderveer/go-dynamo/dynamotest"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // tablename returns the test table name or fail the test
@@ -23,6 +26,34 @@ func tablename(tb testing.TB) string {
 	return tname
 }
 
+// newdb returns the api to test against together with the table name. When
+// DYNAMO_RECORD is set the requests to the real table are recorded to a golden
+// file in testdata, when DYNAMO_REPLAY is set they are replayed from it such
+// that no table (or credentials) are needed.
+func newdb(tb testing.TB) (dynamodbiface.DynamoDBAPI, string) {
+	golden := filepath.Join("testdata", tb.Name()+".json")
+	if os.Getenv("DYNAMO_REPLAY") != "" {
+		rp, err := dynamotest.LoadReplayer(golden)
+		if err != nil {
+			tb.Fatal("failed to load golden file", err)
+		}
+
+		tb.Cleanup(func() { ok(tb, rp.Verify()) })
+		return rp, rp.Meta("table")
+	}
+
+	tname := tablename(tb)
+	db := dynamodb.New(newsess(tb))
+	if os.Getenv("DYNAMO_RECORD") == "" {
+		return db, tname
+	}
+
+	rec := dynamotest.NewRecorder(db)
+	rec.SetMeta("table", tname)
+	tb.Cleanup(func() { ok(tb, rec.Save(golden)) })
+	return rec, tname
+}
+
 // newsess will try to setup an aws session from the environment or fail
 func newsess(tb testing.TB) *session.Session {
 	cfg := &aws.Config{
@@ -0,0 +1,40 @@
+package dynamo
+
+import (
+	"github.com/advanderveer/go-dynamo/codec"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//ItemFormat determines how items are represented as JSON
+type ItemFormat int
+
+const (
+	//FormatDynamoDB represents items in the typed DynamoDB JSON format, e.g.
+	//{"Name":{"S":"a"}}, it preserves all types such that items are restored
+	//exactly
+	FormatDynamoDB ItemFormat = iota
+
+	//FormatPlain represents items as plain JSON, e.g. {"Name":"a"}. Binary
+	//values become base64 strings and sets become arrays, such that they are
+	//restored as strings and lists.
+	FormatPlain
+)
+
+//EncodeItem encodes an item as a single line of JSON in the provided format
+func EncodeItem(f ItemFormat, item map[string]*dynamodb.AttributeValue) ([]byte, error) {
+	if f == FormatPlain {
+		return codec.MarshalPlain(item)
+	}
+
+	return codec.Marshal(item)
+}
+
+//DecodeItem decodes a JSON object in the provided format into an item,
+//numbers keep their precision
+func DecodeItem(f ItemFormat, data []byte) (map[string]*dynamodb.AttributeValue, error) {
+	if f == FormatPlain {
+		return codec.UnmarshalPlain(data)
+	}
+
+	return codec.Unmarshal(data)
+}
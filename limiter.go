@@ -0,0 +1,87 @@
+package dynamo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+//RateLimiter paces the pages of a query or scan by the capacity they consume
+type RateLimiter interface {
+	//Wait blocks until the next page may be requested
+	Wait(ctx aws.Context) error
+
+	//Consume accounts for capacity units that were consumed by a page
+	Consume(units float64)
+}
+
+//CapacityLimiter is a RateLimiter that caps the consumed capacity units per
+//second. Since the capacity of a page is only known after it was read, pages
+//are allowed as long as the limiter isn't in debt and wait until it is repaid
+//otherwise. It is safe for concurrent use such that it can be shared by the
+//segments of a parallel scan or by multiple jobs.
+type CapacityLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	now   func() time.Time
+	after func(d time.Duration) <-chan time.Time
+}
+
+//NewCapacityLimiter creates a limiter that allows the consumption of the
+//provided number of capacity units per second, on average
+func NewCapacityLimiter(unitsPerSecond float64) *CapacityLimiter {
+	return &CapacityLimiter{
+		rate:  unitsPerSecond,
+		burst: unitsPerSecond,
+		now:   time.Now,
+		after: time.After,
+	}
+}
+
+//refill adds the tokens that accumulated since the last refill, it must be
+//called while holding the lock
+func (l *CapacityLimiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+
+	l.last = now
+}
+
+//Wait blocks until the consumed capacity has been repaid, or the context is done
+func (l *CapacityLimiter) Wait(ctx aws.Context) error {
+	for {
+		l.mu.Lock()
+		l.refill()
+		if l.tokens >= 0 {
+			l.mu.Unlock()
+			return nil
+		}
+
+		d := time.Duration(-l.tokens / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.after(d):
+		}
+	}
+}
+
+//Consume accounts for capacity units that were consumed
+func (l *CapacityLimiter) Consume(units float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens -= units
+}
@@ -0,0 +1,71 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//fakeClock advances when the limiter waits such that tests don't sleep
+type fakeClock struct {
+	now    time.Time
+	waited []time.Duration
+}
+
+func (c *fakeClock) limiter(rate float64) *CapacityLimiter {
+	l := NewCapacityLimiter(rate)
+	l.now = func() time.Time { return c.now }
+	l.after = func(d time.Duration) <-chan time.Time {
+		c.waited = append(c.waited, d)
+		c.now = c.now.Add(d)
+		ch := make(chan time.Time, 1)
+		ch <- c.now
+		return ch
+	}
+
+	return l
+}
+
+func TestCapacityLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := clock.limiter(10)
+
+	ok(t, l.Wait(aws.BackgroundContext()))
+	l.Consume(25)
+	ok(t, l.Wait(aws.BackgroundContext()))
+	equals(t, []time.Duration{2500 * time.Millisecond}, clock.waited)
+
+	//idle time accumulates up to a burst of one second worth of capacity
+	clock.now = clock.now.Add(time.Minute)
+	l.Consume(10)
+	ok(t, l.Wait(aws.BackgroundContext()))
+	equals(t, 1, len(clock.waited))
+}
+
+func TestQueryRateLimited(t *testing.T) {
+	var inputs []*dynamodb.QueryInput
+	serve := pages(1, item("Name", "a"), item("Name", "b"), item("Name", "c"))
+	db := &fakeDB{query: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		inputs = append(inputs, in)
+		out, err := serve(in)
+		out.SetConsumedCapacity(&dynamodb.ConsumedCapacity{CapacityUnits: aws.Float64(5)})
+		return out, err
+	}}
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	q := NewQuery("tbl", "#n = :name")
+	q.AddExpressionName("#n", "Name")
+	q.AddExpressionValue(":name", "a")
+	q.SetMaxPages(AllPages)
+	q.SetRateLimiter(clock.limiter(2))
+
+	var res []named
+	_, err := q.Execute(db, &res)
+	ok(t, err)
+	equals(t, 3, len(res))
+	equals(t, dynamodb.ReturnConsumedCapacityTotal, aws.StringValue(inputs[0].ReturnConsumedCapacity))
+	equals(t, []time.Duration{2500 * time.Millisecond, 2500 * time.Millisecond}, clock.waited)
+	equals(t, (*string)(nil), q.ReturnConsumedCapacity)
+}
@@ -0,0 +1,244 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+//MarshalItem marshals an item like Put does, e.g. to compare items in tests.
+//Encrypted fields use the default encryption.
+func MarshalItem(item interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return marshalItem(item, nil)
+}
+
+//MarshalKey marshals a primary key like Get, Update and Delete do
+func MarshalKey(pk interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return marshalKey(pk)
+}
+
+//MarshalValue marshals an expression attribute value like the builders do
+func MarshalValue(v interface{}) (*dynamodb.AttributeValue, error) {
+	return marshalValue(v)
+}
+
+//UnmarshalItem decodes an item like the builders do, encrypted fields use the
+//default encryption
+func UnmarshalItem(m map[string]*dynamodb.AttributeValue, v interface{}) error {
+	return unmarshalItem(m, v, nil)
+}
+
+//marshalItem marshals an item, values of registered types are encoded with
+//their marshaller, the composite key attributes of registered entities are
+//rendered into the result and fields with coding options (e.g. compress) are
+//encoded with encryption e (nil for the default). Raw attribute maps are used
+//as is.
+func marshalItem(item interface{}, e *Encryption) (map[string]*dynamodb.AttributeValue, error) {
+	if raw, ok := item.(map[string]*dynamodb.AttributeValue); ok {
+		return raw, nil
+	}
+
+	rv := indirectValue(reflect.ValueOf(item))
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("item is nil")
+	}
+
+	m, err := dynamodbattribute.MarshalMap(item)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = encodeItemTypes(item, m); err != nil {
+		return nil, err
+	}
+
+	if ent := DefaultRegistry.lookup(reflect.TypeOf(item)); ent != nil {
+		if err = ent.renderKeys(rv, m, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = encodeFields(reflect.ValueOf(item), m, e); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+//marshalKey marshals a primary key, for registered entities the key consists
+//of only its rendered primary key attributes. Raw attribute maps are used as is.
+func marshalKey(pk interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	if raw, ok := pk.(map[string]*dynamodb.AttributeValue); ok {
+		return raw, nil
+	}
+
+	rv := indirectValue(reflect.ValueOf(pk))
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("primary key is nil")
+	}
+
+	e := DefaultRegistry.lookup(reflect.TypeOf(pk))
+	if e == nil {
+		m, err := dynamodbattribute.MarshalMap(pk)
+		if err != nil {
+			return nil, err
+		}
+
+		return m, encodeItemTypes(pk, m)
+	}
+
+	m := map[string]*dynamodb.AttributeValue{}
+	if err := e.renderKeys(rv, m, false); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+//marshalValues marshals expression attribute values, raw attribute values are
+//used as is
+func marshalValues(vals map[string]interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	m := make(map[string]*dynamodb.AttributeValue, len(vals))
+	for k, v := range vals {
+		av, err := marshalValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value '%s': %+v", k, err)
+		}
+
+		m[k] = av
+	}
+
+	return m, nil
+}
+
+//marshalValue marshals a single value, values of registered types are encoded
+//with their marshaller and raw attribute values are used as is
+func marshalValue(v interface{}) (*dynamodb.AttributeValue, error) {
+	if raw, ok := v.(*dynamodb.AttributeValue); ok {
+		return raw, nil
+	}
+
+	av, err := dynamodbattribute.Marshal(v)
+	if err != nil || !hasTypes(reflect.TypeOf(v)) {
+		return av, err
+	}
+
+	return encodeTypes(reflect.ValueOf(v), av)
+}
+
+//unmarshalItem decodes an item into v, values of registered types are decoded
+//with their marshaller, the fields of registered entities are also set from
+//their composite key attributes and coded fields are decoded with encryption e
+//(nil for the default). A raw attribute map receives the item as is.
+func unmarshalItem(m map[string]*dynamodb.AttributeValue, v interface{}, e *Encryption) error {
+	if raw, ok := v.(*map[string]*dynamodb.AttributeValue); ok {
+		*raw = m
+		return nil
+	}
+
+	fields := codedFields(reflect.TypeOf(v))
+	rest, coded := codedAttributes(m, fields)
+	typed := indirectType(reflect.TypeOf(v))
+	if !hasTypes(typed) {
+		typed = nil
+	}
+
+	if err := dynamodbattribute.UnmarshalMap(stripItemTypes(typed, rest), v); err != nil {
+		return err
+	}
+
+	if typed != nil {
+		if err := decodeTypes(&dynamodb.AttributeValue{M: rest}, reflect.ValueOf(v).Elem()); err != nil {
+			return err
+		}
+	}
+
+	if err := decodeFields(m, coded, reflect.ValueOf(v), fields, e); err != nil {
+		return err
+	}
+
+	if ent := DefaultRegistry.lookup(reflect.TypeOf(v)); ent != nil {
+		return ent.parseKeys(m, indirectValue(reflect.ValueOf(v)))
+	}
+
+	return nil
+}
+
+//unmarshalItems decodes a list of items into v, which must be a pointer to a
+//slice or an ItemsDecoder. The fields of registered entities are also set from
+//their composite key attributes, coded fields are decoded with encryption e
+//(nil for the default). A slice of raw attribute maps receives the items as
+//they are.
+func unmarshalItems(l []map[string]*dynamodb.AttributeValue, v interface{}, e *Encryption) error {
+	switch dst := v.(type) {
+	case encryptedItemsDecoder:
+		return dst.decodeItems(l, e)
+	case ItemsDecoder:
+		return dst.DecodeItems(l)
+	case *[]map[string]*dynamodb.AttributeValue:
+		*dst = l
+		return nil
+	}
+
+	var fields []structField
+	if t := indirectType(reflect.TypeOf(v)); t != nil && t.Kind() == reflect.Slice {
+		fields = codedFields(t.Elem())
+	}
+
+	var typed reflect.Type
+	if t := indirectType(reflect.TypeOf(v)); t != nil && t.Kind() == reflect.Slice && hasTypes(t.Elem()) {
+		typed = t.Elem()
+	}
+
+	rest, coded := l, make([]map[string][]byte, len(l))
+	if len(fields) > 0 || typed != nil {
+		rest = make([]map[string]*dynamodb.AttributeValue, len(l))
+		for i, item := range l {
+			rest[i], coded[i] = codedAttributes(item, fields)
+		}
+	}
+
+	stripped := rest
+	if typed != nil {
+		stripped = make([]map[string]*dynamodb.AttributeValue, len(rest))
+		for i, item := range rest {
+			stripped[i] = stripItemTypes(typed, item)
+		}
+	}
+
+	if err := dynamodbattribute.UnmarshalListOfMaps(stripped, v); err != nil {
+		return err
+	}
+
+	rv := indirectValue(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+
+	for i := 0; i < rv.Len() && i < len(rest) && typed != nil; i++ {
+		if err := decodeTypes(&dynamodb.AttributeValue{M: rest[i]}, rv.Index(i)); err != nil {
+			return fmt.Errorf("failed to decode item %d: %+v", i, err)
+		}
+	}
+
+	for i := 0; i < rv.Len() && i < len(coded) && len(fields) > 0; i++ {
+		if err := decodeFields(l[i], coded[i], rv.Index(i), fields, e); err != nil {
+			return fmt.Errorf("failed to decode item %d: %+v", i, err)
+		}
+	}
+
+	ent := DefaultRegistry.lookup(rv.Type().Elem())
+	if ent == nil {
+		return nil
+	}
+
+	for i := 0; i < rv.Len() && i < len(l); i++ {
+		if err := ent.parseKeys(l[i], indirectValue(rv.Index(i))); err != nil {
+			return fmt.Errorf("failed to decode item %d: %+v", i, err)
+		}
+	}
+
+	return nil
+}
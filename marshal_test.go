@@ -0,0 +1,44 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestRawItems(t *testing.T) {
+	raw := item("Name", "a")
+	db := &fakeDB{
+		putItem: func(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			equals(t, raw, in.Item)
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			equals(t, raw, in.Key)
+			return &dynamodb.GetItemOutput{Item: raw}, nil
+		},
+		query: pages(1, raw, item("Name", "b")),
+	}
+
+	ok(t, NewPut("tbl", raw).Execute(db))
+
+	var got map[string]*dynamodb.AttributeValue
+	ok(t, NewGet("tbl", raw).Execute(db, &got))
+	equals(t, raw, got)
+
+	q := NewQuery("tbl", "#n = :name")
+	q.AddExpressionName("#n", "Name")
+	q.AddExpressionValue(":name", "a")
+	q.SetMaxPages(AllPages)
+	var list []map[string]*dynamodb.AttributeValue
+	_, err := q.ExecuteWithContext(aws.BackgroundContext(), db, &list)
+	ok(t, err)
+	equals(t, []map[string]*dynamodb.AttributeValue{raw, item("Name", "b")}, list)
+}
+
+func TestMarshalValues(t *testing.T) {
+	m, err := marshalValues(map[string]interface{}{":a": "x", ":b": &dynamodb.AttributeValue{N: aws.String("1")}})
+	ok(t, err)
+	equals(t, map[string]*dynamodb.AttributeValue{":a": {S: aws.String("x")}, ":b": {N: aws.String("1")}}, m)
+}
@@ -0,0 +1,251 @@
+package dynamo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Observation describes the outcome of a single builder execution
+type Observation struct {
+	Operation        string
+	Table            string
+	Index            string
+	Duration         time.Duration
+	ErrorClass       string
+	NotFound         bool
+	Items            int64
+	Pages            int
+	Throttles        int
+	ConsumedCapacity float64
+}
+
+//Metrics receives an observation for every execution of a builder
+type Metrics interface {
+	Observe(o Observation)
+}
+
+//DefaultMetrics receives observations from builders that have no metrics
+//configured explicitly, it should be set during program initialization
+var DefaultMetrics Metrics
+
+//MetricsInput is used when an execution can be measured
+type MetricsInput struct {
+	Metrics Metrics
+}
+
+//SetMetrics configures the sink that receives an observation for each execution
+func (mi *MetricsInput) SetMetrics(m Metrics) { mi.Metrics = m }
+
+//DefaultLatencyBuckets are the upper bounds (in seconds) of the latency histogram
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+//SeriesKey identifies the operation on a table or index that is measured
+type SeriesKey struct {
+	Operation string
+	Table     string
+	Index     string
+}
+
+//Series holds the measurements of a single operation on a table or index
+type Series struct {
+	SeriesKey
+	Calls            int64
+	Errors           map[string]int64
+	NotFound         int64
+	Items            int64
+	Pages            int64
+	Throttles        int64
+	ConsumedCapacity float64
+
+	//LatencyBuckets holds the cumulative count of calls that took at most
+	//the corresponding upper bound in LatencyBounds
+	LatencyBounds  []float64
+	LatencyBuckets []int64
+	LatencySum     float64
+}
+
+//MemoryMetrics aggregates observations in-process, it is safe for concurrent use
+type MemoryMetrics struct {
+	buckets []float64
+	mu      sync.Mutex
+	series  map[SeriesKey]*Series
+}
+
+//NewMemoryMetrics creates an in-process metrics sink with the provided latency
+//buckets (in seconds), the DefaultLatencyBuckets are used when none are provided
+func NewMemoryMetrics(buckets ...float64) *MemoryMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	return &MemoryMetrics{buckets: buckets, series: map[SeriesKey]*Series{}}
+}
+
+//Observe aggregates the observation into the series of its operation
+func (m *MemoryMetrics) Observe(o Observation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := SeriesKey{o.Operation, o.Table, o.Index}
+	s, ok := m.series[k]
+	if !ok {
+		s = &Series{
+			SeriesKey:      k,
+			Errors:         map[string]int64{},
+			LatencyBounds:  m.buckets,
+			LatencyBuckets: make([]int64, len(m.buckets)),
+		}
+
+		m.series[k] = s
+	}
+
+	s.Calls++
+	if o.ErrorClass != "" {
+		s.Errors[o.ErrorClass]++
+	}
+
+	if o.NotFound {
+		s.NotFound++
+	}
+
+	s.Items += o.Items
+	s.Pages += int64(o.Pages)
+	s.Throttles += int64(o.Throttles)
+	s.ConsumedCapacity += o.ConsumedCapacity
+
+	secs := o.Duration.Seconds()
+	s.LatencySum += secs
+	for i, b := range m.buckets {
+		if secs <= b {
+			s.LatencyBuckets[i]++
+		}
+	}
+}
+
+//Snapshot returns a copy of all series, ordered by operation, table and index
+func (m *MemoryMetrics) Snapshot() (snap []Series) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.series {
+		cpy := *s
+		cpy.Errors = map[string]int64{}
+		for class, n := range s.Errors {
+			cpy.Errors[class] = n
+		}
+
+		cpy.LatencyBuckets = append([]int64{}, s.LatencyBuckets...)
+		snap = append(snap, cpy)
+	}
+
+	sort.Slice(snap, func(i, j int) bool {
+		a, b := snap[i].SeriesKey, snap[j].SeriesKey
+		if a.Operation != b.Operation {
+			return a.Operation < b.Operation
+		}
+
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+
+		return a.Index < b.Index
+	})
+
+	return snap
+}
+
+//WritePrometheus writes a snapshot of all series in the Prometheus text
+//exposition format
+func (m *MemoryMetrics) WritePrometheus(w io.Writer) (err error) {
+	snap := m.Snapshot()
+	pw := &promWriter{w: w}
+
+	pw.family("dynamo_calls_total", "counter", "Number of executions.")
+	for _, s := range snap {
+		pw.sample("dynamo_calls_total", labels(s.SeriesKey), float64(s.Calls))
+	}
+
+	pw.family("dynamo_errors_total", "counter", "Number of failed executions by error class.")
+	for _, s := range snap {
+		classes := make([]string, 0, len(s.Errors))
+		for class := range s.Errors {
+			classes = append(classes, class)
+		}
+
+		sort.Strings(classes)
+		for _, class := range classes {
+			pw.sample("dynamo_errors_total", labels(s.SeriesKey, "class", class), float64(s.Errors[class]))
+		}
+	}
+
+	pw.family("dynamo_latency_seconds", "histogram", "Latency of executions.")
+	for _, s := range snap {
+		for i, b := range s.LatencyBounds {
+			pw.sample("dynamo_latency_seconds_bucket", labels(s.SeriesKey, "le", strconv.FormatFloat(b, 'g', -1, 64)), float64(s.LatencyBuckets[i]))
+		}
+
+		pw.sample("dynamo_latency_seconds_bucket", labels(s.SeriesKey, "le", "+Inf"), float64(s.Calls))
+		pw.sample("dynamo_latency_seconds_sum", labels(s.SeriesKey), s.LatencySum)
+		pw.sample("dynamo_latency_seconds_count", labels(s.SeriesKey), float64(s.Calls))
+	}
+
+	for _, c := range []struct {
+		name, help string
+		val        func(s Series) float64
+	}{
+		{"dynamo_not_found_total", "Number of gets that found no item.", func(s Series) float64 { return float64(s.NotFound) }},
+		{"dynamo_items_total", "Number of items returned or written.", func(s Series) float64 { return float64(s.Items) }},
+		{"dynamo_pages_total", "Number of pages fetched.", func(s Series) float64 { return float64(s.Pages) }},
+		{"dynamo_throttles_total", "Number of throttled requests.", func(s Series) float64 { return float64(s.Throttles) }},
+		{"dynamo_consumed_capacity_total", "Capacity units consumed.", func(s Series) float64 { return s.ConsumedCapacity }},
+	} {
+		pw.family(c.name, "counter", c.help)
+		for _, s := range snap {
+			pw.sample(c.name, labels(s.SeriesKey), c.val(s))
+		}
+	}
+
+	return pw.err
+}
+
+//labels formats the series key and additional label pairs
+func labels(k SeriesKey, pairs ...string) string {
+	pairs = append([]string{"operation", k.Operation, "table", k.Table, "index", k.Index}, pairs...)
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+promEscaper.Replace(pairs[i+1])+`"`)
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var promEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+//promWriter writes the exposition format while remembering the first error
+type promWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (pw *promWriter) family(name, typ, help string) {
+	pw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (pw *promWriter) sample(name, labels string, v float64) {
+	pw.printf("%s%s %s\n", name, labels, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+func (pw *promWriter) printf(format string, args ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+
+	_, pw.err = fmt.Fprintf(pw.w, format, args...)
+}
@@ -0,0 +1,88 @@
+package dynamo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestMetricsObservations(t *testing.T) {
+	m := NewMemoryMetrics()
+	db := &fakeDB{
+		query: pages(1, item("Name", "a"), item("Name", "b")),
+		getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return nil, awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil)
+		},
+	}
+
+	q := NewQuery("tbl", "Name = :name")
+	q.AddExpressionValue(":name", "a")
+	q.SetMetrics(m)
+	q.SetMaxPages(2)
+	_, err := q.Execute(db, &[]struct{ Name string }{})
+	ok(t, err)
+
+	g := NewGet("tbl", struct{ Name string }{"a"})
+	g.SetMetrics(m)
+	assert(t, g.Execute(db, &struct{ Name string }{}) != nil, "expected get to fail")
+
+	snap := m.Snapshot()
+	equals(t, 2, len(snap))
+	equals(t, SeriesKey{"GetItem", "tbl", ""}, snap[0].SeriesKey)
+	equals(t, int64(1), snap[0].Calls)
+	equals(t, int64(1), snap[0].Throttles)
+	equals(t, map[string]int64{ErrClassThrottled: 1}, snap[0].Errors)
+
+	equals(t, SeriesKey{"Query", "tbl", ""}, snap[1].SeriesKey)
+	equals(t, int64(2), snap[1].Items)
+	equals(t, int64(2), snap[1].Pages)
+	equals(t, 0, len(snap[1].Errors))
+}
+
+func TestMetricsNotFound(t *testing.T) {
+	m := NewMemoryMetrics()
+	db := &fakeDB{getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		return &dynamodb.GetItemOutput{}, nil
+	}}
+
+	g := NewGet("tbl", struct{ Name string }{"a"})
+	g.SetMetrics(m)
+	g.SetItemNilError(errExists)
+	equals(t, errExists, g.Execute(db, &struct{ Name string }{}))
+
+	snap := m.Snapshot()
+	equals(t, int64(1), snap[0].Calls)
+	equals(t, int64(1), snap[0].NotFound)
+	equals(t, 0, len(snap[0].Errors))
+
+	buf := bytes.NewBuffer(nil)
+	ok(t, m.WritePrometheus(buf))
+	assert(t, strings.Contains(buf.String(), `dynamo_not_found_total{operation="GetItem",table="tbl",index=""} 1`+"\n"), "expected not found count, got:\n%s", buf.String())
+}
+
+func TestMetricsPrometheus(t *testing.T) {
+	m := NewMemoryMetrics(0.1, 1)
+	m.Observe(Observation{Operation: "Query", Table: "tbl", Index: "idx", Duration: 500 * time.Millisecond, Items: 3, Pages: 1})
+	m.Observe(Observation{Operation: "Query", Table: "tbl", Index: "idx", Duration: 2 * time.Second, ErrorClass: ErrClassThrottled, Throttles: 1})
+
+	buf := bytes.NewBuffer(nil)
+	ok(t, m.WritePrometheus(buf))
+
+	for _, line := range []string{
+		`# TYPE dynamo_calls_total counter`,
+		`dynamo_calls_total{operation="Query",table="tbl",index="idx"} 2`,
+		`dynamo_errors_total{operation="Query",table="tbl",index="idx",class="throttled"} 1`,
+		`dynamo_latency_seconds_bucket{operation="Query",table="tbl",index="idx",le="0.1"} 0`,
+		`dynamo_latency_seconds_bucket{operation="Query",table="tbl",index="idx",le="1"} 1`,
+		`dynamo_latency_seconds_bucket{operation="Query",table="tbl",index="idx",le="+Inf"} 2`,
+		`dynamo_latency_seconds_sum{operation="Query",table="tbl",index="idx"} 2.5`,
+		`dynamo_items_total{operation="Query",table="tbl",index="idx"} 3`,
+		`dynamo_throttles_total{operation="Query",table="tbl",index="idx"} 1`,
+	} {
+		assert(t, strings.Contains(buf.String(), line+"\n"), "expected output to contain %q, got:\n%s", line, buf.String())
+	}
+}
@@ -0,0 +1,216 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//page holds the parts of a query, scan or statement response that are used
+//while paging
+type page struct {
+	Items            []map[string]*dynamodb.AttributeValue
+	Count            int64
+	ScannedCount     int64
+	Next             cursor
+	ConsumedCapacity *dynamodb.ConsumedCapacity
+}
+
+//cursor is the position that a page starts at: the exclusive start key of
+//queries and scans or the next token of statements
+type cursor struct {
+	Key   map[string]*dynamodb.AttributeValue
+	Token *string
+}
+
+//done reports whether there are no more pages after the cursor
+func (cur cursor) done() bool { return len(cur.Key) == 0 && aws.StringValue(cur.Token) == "" }
+
+//fetchFunc requests a single page that starts at the cursor, remaining is the
+//number of items that MaxItems still allows (zero if it isn't set) for requests
+//that can't resume within a page
+type fetchFunc func(ctx aws.Context, start cursor, remaining int) (*page, error)
+
+//AllPages can be passed to SetMaxPages to fetch every page
+const AllPages = -1
+
+//result holds the totals of paginating a query, scan or statement
+type result struct {
+	Count        int64
+	ScannedCount int64
+	Next         cursor
+}
+
+//paginate fetches pages, starting at the cursor, until the paging limits are
+//reached and decodes the items of all pages into items with encryption e.
+//Failed requests are converted by mapErr.
+func paginate(c *call, pi PagingInput, start cursor, fetch fetchFunc, mapErr func(error) error, e *Encryption, items interface{}) (res result, err error) {
+	maxPages := pi.MaxPages
+	if maxPages == 0 {
+		maxPages = 1
+		if pi.MaxItems > 0 {
+			maxPages = AllPages
+		}
+	}
+
+	//the key to resume from is determined for truncated pages, the names of
+	//its attributes must be known before any page is read
+	var keys []string
+	if pi.resume && pi.MaxItems > 0 {
+		if keys = resumeKeyNames(pi, start, items); len(keys) == 0 {
+			return res, fmt.Errorf("failed to determine key to resume from: key attributes are unknown, configure them with SetKeyAttributes")
+		}
+	}
+
+	var all []map[string]*dynamodb.AttributeValue
+	for {
+		if pi.RateLimiter != nil {
+			if err = pi.RateLimiter.Wait(c.ctx); err != nil {
+				c.fail(err)
+				return res, fmt.Errorf("failed to wait for rate limiter: %+v", err)
+			}
+		}
+
+		remaining := 0
+		if pi.MaxItems > 0 {
+			remaining = pi.MaxItems - len(all)
+		}
+
+		ctx, span := c.startPage()
+		pg, err := fetch(ctx, start, remaining)
+		c.endPage(span, pg, err)
+		if err != nil {
+			c.fail(err)
+			return res, mapErr(err)
+		}
+
+		if pi.RateLimiter != nil && pg.ConsumedCapacity != nil {
+			pi.RateLimiter.Consume(aws.Float64Value(pg.ConsumedCapacity.CapacityUnits))
+		}
+
+		res.Count += pg.Count
+		res.ScannedCount += pg.ScannedCount
+		c.capacity(pg.ConsumedCapacity)
+		all = append(all, pg.Items...)
+
+		start = pg.Next
+		res.Next = start
+
+		if pi.MaxItems > 0 && len(all) >= pi.MaxItems {
+			if len(all) > pi.MaxItems {
+				res.Count -= int64(len(all) - pi.MaxItems)
+				all = all[:pi.MaxItems]
+				res.Next = cursor{}
+				if pi.resume {
+					if res.Next.Key, err = resumeKey(all[len(all)-1], keys); err != nil {
+						return res, fmt.Errorf("failed to determine key to resume from: %+v", err)
+					}
+				}
+			}
+
+			break
+		}
+
+		if start.done() || (maxPages > 0 && c.obs.Pages >= maxPages) {
+			break
+		}
+	}
+
+	c.items(res.Count)
+	if len(all) > 0 && items != nil {
+		if err = unmarshalItems(all, items, e); err != nil {
+			return res, fmt.Errorf("failed to unmarshal items: %+v", err)
+		}
+	}
+
+	return res, nil
+}
+
+//requestError converts the error of a failed request
+func requestError(err error) error {
+	return fmt.Errorf("failed to perform request: %+v", err)
+}
+
+//resumeKeyNames returns the names of the key attributes that the key to resume
+//from consists of: those that are configured (or of the index), those of the
+//start key or the primary key attributes of the registered entity that items
+//decode into
+func resumeKeyNames(pi PagingInput, start cursor, items interface{}) (names []string) {
+	if len(pi.KeyAttributes) > 0 {
+		return pi.KeyAttributes
+	}
+
+	for name := range start.Key {
+		names = append(names, name)
+	}
+
+	if len(names) > 0 {
+		return names
+	}
+
+	t := indirectType(reflect.TypeOf(items))
+	if t == nil || t.Kind() != reflect.Slice {
+		return nil
+	}
+
+	if ent := DefaultRegistry.lookup(t.Elem()); ent != nil {
+		for name := range ent.primary {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+//resumeKey returns the key of an item that a query or scan can resume from
+func resumeKey(item map[string]*dynamodb.AttributeValue, names []string) (map[string]*dynamodb.AttributeValue, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("key attributes are unknown, configure them with SetKeyAttributes")
+	}
+
+	key := map[string]*dynamodb.AttributeValue{}
+	for _, name := range names {
+		av, ok := item[name]
+		if !ok {
+			return nil, fmt.Errorf("item has no key attribute '%s', make sure it is projected", name)
+		}
+
+		key[name] = av
+	}
+
+	return key, nil
+}
+
+//countInput prepares the expressions of a query or scan for counting: the
+//projection expression can't be combined with counting so it is removed,
+//together with the names that only it referenced
+func countInput(proj **string, names map[string]*string, exprs ...*string) map[string]*string {
+	if *proj == nil {
+		return names
+	}
+
+	*proj = nil
+	used := map[string]bool{}
+	for _, expr := range exprs {
+		for _, tok := range scanExpression(aws.StringValue(expr)) {
+			used[tok.Text] = true
+		}
+	}
+
+	pruned := map[string]*string{}
+	for ph, name := range names {
+		if used[ph] {
+			pruned[ph] = name
+		}
+	}
+
+	if len(pruned) == 0 {
+		return nil
+	}
+
+	return pruned
+}
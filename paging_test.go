@@ -0,0 +1,147 @@
+package dynamo
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type position struct {
+	Pos int
+}
+
+// filtered returns a query function that evaluates n items per page and only
+// returns the items that pass the filter, like DynamoDB does with a Limit
+func filtered(n, total int, pass func(pos int) bool) func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	return func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		start := 0
+		if in.ExclusiveStartKey != nil {
+			start, _ = strconv.Atoi(aws.StringValue(in.ExclusiveStartKey["Pos"].N))
+			start++
+		}
+
+		out := &dynamodb.QueryOutput{}
+		end := start
+		for ; end < start+n && end < total; end++ {
+			if pass(end) {
+				out.Items = append(out.Items, map[string]*dynamodb.AttributeValue{"Pos": {N: aws.String(strconv.Itoa(end))}})
+			}
+		}
+
+		out.SetCount(int64(len(out.Items)))
+		out.SetScannedCount(int64(end - start))
+		if end < total {
+			out.SetLastEvaluatedKey(map[string]*dynamodb.AttributeValue{"Pos": {N: aws.String(strconv.Itoa(end - 1))}})
+		}
+
+		return out, nil
+	}
+}
+
+func TestQueryMaxItems(t *testing.T) {
+	db := &fakeDB{query: filtered(2, 6, func(pos int) bool { return pos != 2 })}
+	ctx := aws.BackgroundContext()
+
+	var all []position
+	var next map[string]*dynamodb.AttributeValue
+	for _, exp := range []struct {
+		items []position
+		next  string
+	}{
+		{[]position{{0}, {1}, {3}}, "3"},
+		{[]position{{4}, {5}}, ""},
+	} {
+		q := NewQuery("tbl", "Part = :part")
+		q.AddExpressionValue(":part", 1)
+		q.SetMaxItems(3)
+		q.SetKeyAttributes("Pos")
+		q.SetExclusiveStartKey(next)
+
+		list := []position{}
+		n, k, err := q.ExecutePage(ctx, db, &list)
+		ok(t, err)
+		equals(t, exp.items, list)
+		equals(t, int64(len(exp.items)), n)
+		if exp.next == "" {
+			equals(t, 0, len(k))
+		} else {
+			equals(t, exp.next, aws.StringValue(k["Pos"].N))
+		}
+
+		all, next = append(all, list...), k
+	}
+
+	equals(t, 5, len(all))
+}
+
+func TestQueryMaxItemsTruncated(t *testing.T) {
+	db := &fakeDB{query: filtered(4, 6, func(pos int) bool { return true })}
+
+	q := NewQuery("tbl", "Part = :part")
+	q.AddExpressionValue(":part", 1)
+	q.SetMaxItems(3)
+	q.SetKeyAttributes("Pos")
+	list := []position{}
+	_, next, err := q.ExecutePage(aws.BackgroundContext(), db, &list)
+	ok(t, err)
+	equals(t, []position{{0}, {1}, {2}}, list)
+	equals(t, "2", aws.StringValue(next["Pos"].N))
+
+	q.SetExclusiveStartKey(next)
+	list = []position{}
+	_, next, err = q.ExecutePage(aws.BackgroundContext(), db, &list)
+	ok(t, err)
+	equals(t, []position{{3}, {4}, {5}}, list)
+	equals(t, 0, len(next))
+
+	//a single page without a last evaluated key is truncated, the key names
+	//can't be derived from it so they must be known before reading
+	reads := 0
+	query := filtered(10, 6, func(pos int) bool { return true })
+	db = &fakeDB{query: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		reads++
+		return query(in)
+	}}
+
+	q = NewQuery("tbl", "Part = :part")
+	q.AddExpressionValue(":part", 1)
+	q.SetMaxItems(3)
+	_, _, err = q.ExecutePage(aws.BackgroundContext(), db, &list)
+	equals(t, "failed to determine key to resume from: key attributes are unknown, configure them with SetKeyAttributes", err.Error())
+	equals(t, 0, reads)
+
+	list = []position{}
+	n, err := q.Execute(db, &list)
+	ok(t, err)
+	equals(t, int64(3), n)
+	equals(t, []position{{0}, {1}, {2}}, list)
+
+	q.SetKeyAttributes("Pos")
+	_, next, err = q.ExecutePage(aws.BackgroundContext(), db, &list)
+	ok(t, err)
+	equals(t, "2", aws.StringValue(next["Pos"].N))
+}
+
+type registeredPosition struct {
+	Part int
+	Pos  int
+}
+
+func TestQueryMaxItemsRegisteredKeys(t *testing.T) {
+	r := NewRegistry()
+	ok(t, r.Register(registeredPosition{}, EntityKeys{Primary: map[string]string{"Pos": "{Pos}"}}))
+	defer func(old *Registry) { DefaultRegistry = old }(DefaultRegistry)
+	DefaultRegistry = r
+
+	db := &fakeDB{query: filtered(10, 6, func(pos int) bool { return true })}
+	q := NewQuery("tbl", "Part = :part")
+	q.AddExpressionValue(":part", 1)
+	q.SetMaxItems(2)
+	list := []registeredPosition{}
+	_, next, err := q.ExecutePage(aws.BackgroundContext(), db, &list)
+	ok(t, err)
+	equals(t, 2, len(list))
+	equals(t, "1", aws.StringValue(next["Pos"].N))
+}
@@ -0,0 +1,201 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//ItemsDecoder can be passed to a query or scan as the destination of the items
+//to take control over how the raw items are decoded
+type ItemsDecoder interface {
+	DecodeItems(items []map[string]*dynamodb.AttributeValue) error
+}
+
+//encryptedItemsDecoder is implemented by the package's decoders such that the
+//encryption of the execution is used for the encrypted fields
+type encryptedItemsDecoder interface {
+	decodeItems(items []map[string]*dynamodb.AttributeValue, e *Encryption) error
+}
+
+//TypeMap maps items onto Go types by the value of a discriminator attribute
+//or by the prefix of a (sort key) attribute
+type TypeMap struct {
+	rules []typeRule
+}
+
+//typeRule maps items with a matching attribute onto a struct type
+type typeRule struct {
+	attr   string
+	value  string
+	prefix bool
+	typ    reflect.Type
+}
+
+//NewTypeMap creates an empty type map
+func NewTypeMap() *TypeMap {
+	return &TypeMap{}
+}
+
+//RegisterValue maps items whose attribute attr has the string value onto the type of v
+func (tm *TypeMap) RegisterValue(attr, value string, v interface{}) *TypeMap {
+	tm.rules = append(tm.rules, typeRule{attr: attr, value: value, typ: indirectType(reflect.TypeOf(v))})
+	return tm
+}
+
+//RegisterPrefix maps items whose string attribute attr starts with the prefix
+//onto the type of v, rules are evaluated in the order they are registered
+func (tm *TypeMap) RegisterPrefix(attr, prefix string, v interface{}) *TypeMap {
+	tm.rules = append(tm.rules, typeRule{attr: attr, value: prefix, prefix: true, typ: indirectType(reflect.TypeOf(v))})
+	return tm
+}
+
+//Resolve returns the type the item is mapped onto, or nil if no rule matches
+func (tm *TypeMap) Resolve(item map[string]*dynamodb.AttributeValue) reflect.Type {
+	for _, r := range tm.rules {
+		av, ok := item[r.attr]
+		if !ok || av.S == nil {
+			continue
+		}
+
+		if (r.prefix && strings.HasPrefix(aws.StringValue(av.S), r.value)) ||
+			(!r.prefix && aws.StringValue(av.S) == r.value) {
+			return r.typ
+		}
+	}
+
+	return nil
+}
+
+//decode decodes the item into a new value of its mapped type and returns a
+//pointer to it, nil is returned if the item is not mapped. Encrypted fields
+//use e, or the default if it is nil.
+func (tm *TypeMap) decode(item map[string]*dynamodb.AttributeValue, e *Encryption) (interface{}, error) {
+	typ := tm.Resolve(item)
+	if typ == nil {
+		return nil, nil
+	}
+
+	ptr := reflect.New(typ).Interface()
+	if err := unmarshalItem(item, ptr, e); err != nil {
+		return nil, fmt.Errorf("failed to decode item as %s: %+v", typ, err)
+	}
+
+	return ptr, nil
+}
+
+//Dispatch returns a destination for a query or scan that decodes each item
+//into a new value of its mapped type and calls fn with a pointer to it. Items
+//that are not mapped are passed to fn as their raw attribute value map.
+func (tm *TypeMap) Dispatch(fn func(v interface{}) error) ItemsDecoder {
+	return dispatcher{tm, fn}
+}
+
+type dispatcher struct {
+	types *TypeMap
+	fn    func(v interface{}) error
+}
+
+func (d dispatcher) DecodeItems(items []map[string]*dynamodb.AttributeValue) error {
+	return d.decodeItems(items, nil)
+}
+
+func (d dispatcher) decodeItems(items []map[string]*dynamodb.AttributeValue, e *Encryption) error {
+	for _, item := range items {
+		v, err := d.types.decode(item, e)
+		if err != nil {
+			return err
+		}
+
+		if v == nil {
+			if err = d.fn(item); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err = d.fn(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Collection is a destination for a query or scan that holds items of several
+//types, e.g. a user together with its orders and addresses
+type Collection struct {
+	types   *TypeMap
+	values  map[reflect.Type][]reflect.Value
+	Unknown []map[string]*dynamodb.AttributeValue
+}
+
+//NewCollection creates a collection that decodes items using the type map
+func NewCollection(tm *TypeMap) *Collection {
+	return &Collection{types: tm, values: map[reflect.Type][]reflect.Value{}}
+}
+
+//DecodeItems decodes the items and adds them to the collection, items that
+//are not mapped are kept in Unknown. Encrypted fields use the default encryption,
+//or the encryption of the query or scan the collection is passed to.
+func (c *Collection) DecodeItems(items []map[string]*dynamodb.AttributeValue) error {
+	return c.decodeItems(items, nil)
+}
+
+func (c *Collection) decodeItems(items []map[string]*dynamodb.AttributeValue, e *Encryption) error {
+	for _, item := range items {
+		v, err := c.types.decode(item, e)
+		if err != nil {
+			return err
+		}
+
+		if v == nil {
+			c.Unknown = append(c.Unknown, item)
+			continue
+		}
+
+		rv := reflect.ValueOf(v)
+		c.values[rv.Type().Elem()] = append(c.values[rv.Type().Elem()], rv)
+	}
+
+	return nil
+}
+
+//Get sets the items of a type in the collection, v must be a pointer to a
+//slice of structs or of pointers to structs, e.g. *[]Order or *[]*Order
+func (c *Collection) Get(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected a pointer to a slice, got: %T", v)
+	}
+
+	slice := rv.Elem()
+	elem := slice.Type().Elem()
+	vals := c.values[indirectType(elem)]
+
+	out := reflect.MakeSlice(slice.Type(), 0, len(vals))
+	for _, ptr := range vals {
+		if elem.Kind() == reflect.Ptr {
+			out = reflect.Append(out, ptr)
+			continue
+		}
+
+		out = reflect.Append(out, ptr.Elem())
+	}
+
+	slice.Set(out)
+	return nil
+}
+
+//Len returns the number of items in the collection, including unknown items
+func (c *Collection) Len() (n int) {
+	for _, vals := range c.values {
+		n += len(vals)
+	}
+
+	return n + len(c.Unknown)
+}
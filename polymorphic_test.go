@@ -0,0 +1,65 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type user struct {
+	SK   string
+	Name string
+}
+
+type order struct {
+	SK    string
+	Total int
+}
+
+func TestQueryCollection(t *testing.T) {
+	items := []map[string]*dynamodb.AttributeValue{
+		{"SK": {S: aws.String("USER#1")}, "Name": {S: aws.String("alice")}},
+		{"SK": {S: aws.String("ORDER#1")}, "Total": {N: aws.String("10")}},
+		{"SK": {S: aws.String("ORDER#2")}, "Total": {N: aws.String("20")}},
+		{"SK": {S: aws.String("ADDRESS#1")}},
+	}
+
+	db := &fakeDB{query: pages(10, items...)}
+	tm := NewTypeMap().
+		RegisterPrefix("SK", "USER#", user{}).
+		RegisterPrefix("SK", "ORDER#", &order{})
+
+	coll := NewCollection(tm)
+	q := NewQuery("tbl", "PK = :pk")
+	q.AddExpressionValue(":pk", "GAME#1")
+	_, err := q.Execute(db, coll)
+	ok(t, err)
+	equals(t, 4, coll.Len())
+
+	users := []user{}
+	ok(t, coll.Get(&users))
+	equals(t, []user{{"USER#1", "alice"}}, users)
+
+	orders := []*order{}
+	ok(t, coll.Get(&orders))
+	equals(t, []*order{{"ORDER#1", 10}, {"ORDER#2", 20}}, orders)
+	equals(t, 1, len(coll.Unknown))
+
+	var seen []interface{}
+	_, err = q.Execute(db, tm.Dispatch(func(v interface{}) error {
+		seen = append(seen, v)
+		return nil
+	}))
+	ok(t, err)
+	equals(t, 4, len(seen))
+	equals(t, &user{"USER#1", "alice"}, seen[0])
+	equals(t, &order{"ORDER#2", 20}, seen[2])
+	equals(t, items[3], seen[3])
+}
+
+func TestTypeMapDiscriminator(t *testing.T) {
+	tm := NewTypeMap().RegisterValue("Type", "user", user{})
+	equals(t, nil, tm.Resolve(item("Type", "order")))
+	equals(t, "user", tm.Resolve(item("Type", "user")).Name())
+}
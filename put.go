@@ -5,16 +5,19 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 //Put holds configuration for getting an item
 type Put struct {
 	ExpressionHolder
+	TracingInput
+	MetricsInput
 	dynamodb.PutItemInput
 	ConditionInput
+	ChunkingInput
+	EncryptionInput
 	Item interface{}
 }
 
@@ -25,30 +28,48 @@ func NewPut(tname string, item interface{}) *Put {
 	}, Item: item}
 }
 
+//Clone returns a copy of the put that can be configured independently, the
+//item value is shared
+func (inp *Put) Clone() *Put {
+	c := *inp
+	c.ExpressionHolder = inp.ExpressionHolder.clone()
+	c.PutItemInput = *awsutil.CopyOf(&inp.PutItemInput).(*dynamodb.PutItemInput)
+	return &c
+}
+
 // Execute will perform the put with a background context
-func (inp *Put) Execute(db dynamodbiface.DynamoDBAPI) (err error) {
+func (inp *Put) Execute(db Client) (err error) {
 	return inp.ExecuteWithContext(aws.BackgroundContext(), db)
 }
 
 // ExecuteWithContext will put a item into a DynamoDB table
-func (inp *Put) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDBAPI) (err error) {
-	it, err := dynamodbattribute.MarshalMap(inp.Item)
+func (inp *Put) ExecuteWithContext(ctx aws.Context, db Client) (err error) {
+	c := startCall(ctx, inp.Tracer, inp.Metrics, "PutItem", inp.TableName, nil)
+	defer func() { c.end(err) }()
+
+	names, values, err := inp.expressionAttributes(inp.ConditionExpression)
 	if err != nil {
-		return fmt.Errorf("failed to marshal item map: %+v", err)
+		return err
 	}
 
-	inp.SetItem(it)
-	if len(inp.ExpAttrNames) > 0 {
-		inp.SetExpressionAttributeNames(aws.StringMap(inp.ExpAttrNames))
+	it, err := marshalItem(inp.Item, inp.Encryption)
+	if err != nil {
+		return fmt.Errorf("failed to marshal item map: %+v", err)
 	}
 
-	if len(inp.ExpAttrValues) > 0 {
-		if inp.ExpressionAttributeValues, err = dynamodbattribute.MarshalMap(inp.ExpAttrValues); err != nil {
-			return fmt.Errorf("failed to marshal expression values: %+v", err)
-		}
+	in := inp.PutItemInput
+	in.SetItem(it)
+	in.ExpressionAttributeNames, in.ExpressionAttributeValues = names, values
+
+	var out *dynamodb.PutItemOutput
+	if inp.Chunking != nil {
+		out, err = inp.Chunking.put(c.ctx, db, &in)
+	} else {
+		out, err = db.PutItemWithContext(c.ctx, &in)
 	}
 
-	if _, err = db.PutItemWithContext(ctx, &inp.PutItemInput); err != nil {
+	if err != nil {
+		c.fail(err)
 		aerr, ok := err.(awserr.Error)
 		if !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
 			return fmt.Errorf("failed to perform request: %+v", err)
@@ -61,5 +82,7 @@ func (inp *Put) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDBAPI
 		return err
 	}
 
+	c.items(1)
+	c.capacity(out.ConsumedCapacity)
 	return nil
 }
@@ -4,16 +4,19 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 //Query holds configuration for a query
 type Query struct {
 	PagingInput
+	TracingInput
+	MetricsInput
+	EncryptionInput
 	ExpressionHolder
 	dynamodb.QueryInput
+	Index *Index
 }
 
 //NewQuery prepares a query with it mandatory elements
@@ -24,50 +27,110 @@ func NewQuery(tname, kcond string) *Query {
 	}}
 }
 
+//OnIndex configures the query to read from the index, the query is validated
+//against the index before it is executed. A nil index reads from the table.
+func (inp *Query) OnIndex(idx *Index) *Query {
+	inp.Index = idx
+	if idx == nil {
+		inp.IndexName = nil
+		return inp
+	}
+
+	inp.SetIndexName(idx.Name)
+	return inp
+}
+
+//Clone returns a copy of the query that can be configured independently, e.g.
+//to start from another key. The index, rate limiter and expression values are
+//shared.
+func (inp *Query) Clone() *Query {
+	c := *inp
+	c.ExpressionHolder = inp.ExpressionHolder.clone()
+	c.PagingInput = inp.PagingInput.clone()
+	c.QueryInput = *awsutil.CopyOf(&inp.QueryInput).(*dynamodb.QueryInput)
+	return &c
+}
+
 // Execute will perform the query with a background context
-func (inp *Query) Execute(db dynamodbiface.DynamoDBAPI, items interface{}) (count int64, err error) {
+func (inp *Query) Execute(db Client, items interface{}) (count int64, err error) {
 	return inp.ExecuteWithContext(aws.BackgroundContext(), db, items)
 }
 
 // ExecuteWithContext will perform the query
-func (inp *Query) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDBAPI, items interface{}) (count int64, err error) {
-	if inp.MaxPages == 0 {
-		inp.MaxPages = 1
-	}
+func (inp *Query) ExecuteWithContext(ctx aws.Context, db Client, items interface{}) (count int64, err error) {
+	res, err := inp.execute(ctx, db, items, inp.PagingInput, false)
+	return res.Count, err
+}
+
+//ExecutePage performs the query like ExecuteWithContext but also returns the key
+//to resume from (e.g. with SetExclusiveStartKey), next is nil when there are no
+//more items. When MaxItems truncates the last page the key is positioned at the
+//last returned item.
+func (inp *Query) ExecutePage(ctx aws.Context, db Client, items interface{}) (count int64, next map[string]*dynamodb.AttributeValue, err error) {
+	pi := inp.PagingInput
+	pi.resume = true
+	res, err := inp.execute(ctx, db, items, pi, false)
+	return res.Count, res.Next.Key, err
+}
 
-	if len(inp.ExpAttrNames) > 0 {
-		inp.SetExpressionAttributeNames(aws.StringMap(inp.ExpAttrNames))
+//Count counts the items that match the query (and its filter) across all
+//pages, regardless of MaxPages. It returns the number of matching items and
+//the number of items that were evaluated before the filter was applied.
+func (inp *Query) Count(ctx aws.Context, db Client) (count, scanned int64, err error) {
+	res, err := inp.execute(ctx, db, nil, PagingInput{MaxPages: AllPages, RateLimiter: inp.RateLimiter}, true)
+	return res.Count, res.ScannedCount, err
+}
+
+//execute pages through the query results, it counts instead of retrieving the
+//items when count is true
+func (inp *Query) execute(ctx aws.Context, db Client, items interface{}, pi PagingInput, count bool) (res result, err error) {
+	c := startCall(ctx, inp.Tracer, inp.Metrics, "Query", inp.TableName, inp.IndexName)
+	defer func() { c.end(err) }()
+
+	names, values, err := inp.expressionAttributes(inp.KeyConditionExpression, inp.FilterExpression, inp.ProjectionExpression)
+	if err != nil {
+		return res, err
 	}
 
-	if len(inp.ExpAttrValues) > 0 {
-		if inp.ExpressionAttributeValues, err = dynamodbattribute.MarshalMap(inp.ExpAttrValues); err != nil {
-			return 0, fmt.Errorf("failed to marshal expression values: %+v", err)
-		}
+	in := inp.QueryInput
+	in.ExpressionAttributeNames, in.ExpressionAttributeValues = names, values
+
+	if pi.RateLimiter != nil && aws.StringValue(in.ReturnConsumedCapacity) != dynamodb.ReturnConsumedCapacityIndexes {
+		in.SetReturnConsumedCapacity(dynamodb.ReturnConsumedCapacityTotal)
 	}
 
-	pageNum := 0
-	var lastErr error
-	if err = db.QueryPagesWithContext(ctx, &inp.QueryInput,
-		func(out *dynamodb.QueryOutput, lastPage bool) bool {
-			count += aws.Int64Value(out.Count)
-			pageNum++
-
-			if len(out.Items) > 0 {
-				err = dynamodbattribute.UnmarshalListOfMaps(out.Items, items)
-				if err != nil {
-					lastErr = fmt.Errorf("failed to unmarshal items: %+v", err)
-					return false
-				}
-			}
-
-			return pageNum < inp.MaxPages
-		}); err != nil {
-		return count, fmt.Errorf("failed to perform request: %+v", err)
+	if count {
+		in.SetSelect(dynamodb.SelectCount)
+		in.ExpressionAttributeNames = countInput(&in.ProjectionExpression, in.ExpressionAttributeNames,
+			in.KeyConditionExpression, in.FilterExpression)
+	} else {
+		in.ExpressionAttributeNames = projectBoundKeys(&in.ProjectionExpression, in.ExpressionAttributeNames, items, inp.Encryption)
 	}
 
-	if lastErr != nil {
-		return count, lastErr
+	if inp.Index != nil {
+		if err = inp.Index.validateQuery(&in, items); err != nil {
+			return res, fmt.Errorf("invalid query on index: %+v", err)
+		}
+
+		if len(pi.KeyAttributes) == 0 {
+			pi.KeyAttributes = inp.Index.keyAttributes()
+		}
 	}
 
-	return count, nil
+	return paginate(c, pi, cursor{Key: in.ExclusiveStartKey}, func(ctx aws.Context, start cursor, _ int) (*page, error) {
+		in := in
+		in.ExclusiveStartKey = start.Key
+		out, err := db.QueryWithContext(ctx, &in)
+		if err != nil {
+			return nil, err
+		}
+
+		return &page{
+			Items:            out.Items,
+			Count:            aws.Int64Value(out.Count),
+			ScannedCount:     aws.Int64Value(out.ScannedCount),
+			Next:             cursor{Key: out.LastEvaluatedKey},
+			ConsumedCapacity: out.ConsumedCapacity,
+		}, nil
+	}, requestError, inp.Encryption, items)
 }
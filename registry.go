@@ -0,0 +1,329 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//EntityKeys declares the composite key attributes of an entity as templates
+//that refer to its fields in curly braces, e.g. "GAME#{GameTitle}"
+type EntityKeys struct {
+	//Primary holds the templates of the table's key attributes, they are used
+	//for the item and for primary keys, e.g. {"PK": "GAME#{GameTitle}"}
+	Primary map[string]string
+
+	//Indexes holds the templates of (overloaded) index key attributes, they
+	//are only rendered for the item, e.g. {"GSI1PK": "USER#{UserID}"}
+	Indexes map[string]string
+}
+
+//DefaultRegistry holds the entities that are registered with Register, it is
+//used by the builders when marshalling items and keys and decoding results
+var DefaultRegistry = NewRegistry()
+
+//Register declares the composite keys of an entity in the DefaultRegistry
+func Register(v interface{}, keys EntityKeys) error { return DefaultRegistry.Register(v, keys) }
+
+//Registry holds the composite key templates of entity types, it is safe for
+//concurrent use
+type Registry struct {
+	mu       sync.RWMutex
+	entities map[reflect.Type]*entity
+}
+
+//NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{entities: map[reflect.Type]*entity{}}
+}
+
+//Register declares the composite keys of the entity type of v, which must be
+//a struct (or a pointer to one)
+func (r *Registry) Register(v interface{}, keys EntityKeys) (err error) {
+	t := indirectType(reflect.TypeOf(v))
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("entity must be a struct, got: %T", v)
+	}
+
+	e := &entity{typ: t, primary: map[string]*keyTemplate{}, indexes: map[string]*keyTemplate{}}
+	for attr, tmpl := range keys.Primary {
+		if e.primary[attr], err = parseKeyTemplate(t, tmpl); err != nil {
+			return fmt.Errorf("invalid template for key attribute '%s': %+v", attr, err)
+		}
+	}
+
+	for attr, tmpl := range keys.Indexes {
+		if e.indexes[attr], err = parseKeyTemplate(t, tmpl); err != nil {
+			return fmt.Errorf("invalid template for index key attribute '%s': %+v", attr, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entities[t] = e
+	return nil
+}
+
+//Key renders the composite key attribute of the registered entity v, this is
+//useful for setting expression values, e.g. for a key condition
+func (r *Registry) Key(v interface{}, attr string) (string, error) {
+	e := r.lookup(reflect.TypeOf(v))
+	if e == nil {
+		return "", fmt.Errorf("entity %T is not registered", v)
+	}
+
+	tmpl, ok := e.primary[attr]
+	if !ok {
+		if tmpl, ok = e.indexes[attr]; !ok {
+			return "", fmt.Errorf("entity %T has no key attribute '%s'", v, attr)
+		}
+	}
+
+	rv := indirectValue(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return "", fmt.Errorf("entity %T is nil", v)
+	}
+
+	return tmpl.render(rv)
+}
+
+//lookup returns the registered entity of a (pointer to a) struct type, if any
+func (r *Registry) lookup(t reflect.Type) *entity {
+	t = indirectType(t)
+	if t == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.entities[t]
+}
+
+//entity holds the parsed key templates of a registered type
+type entity struct {
+	typ     reflect.Type
+	primary map[string]*keyTemplate
+	indexes map[string]*keyTemplate
+}
+
+//renderKeys adds the composite key attributes of the entity to m
+func (e *entity) renderKeys(v reflect.Value, m map[string]*dynamodb.AttributeValue, withIndexes bool) error {
+	render := func(tmpls map[string]*keyTemplate) error {
+		for attr, tmpl := range tmpls {
+			s, err := tmpl.render(v)
+			if err != nil {
+				return fmt.Errorf("failed to render key attribute '%s': %+v", attr, err)
+			}
+
+			m[attr] = &dynamodb.AttributeValue{S: aws.String(s)}
+		}
+
+		return nil
+	}
+
+	if err := render(e.primary); err != nil {
+		return err
+	}
+
+	if !withIndexes {
+		return nil
+	}
+
+	return render(e.indexes)
+}
+
+//parseKeys sets the fields of v from the composite key attributes in m
+func (e *entity) parseKeys(m map[string]*dynamodb.AttributeValue, v reflect.Value) error {
+	for _, tmpls := range []map[string]*keyTemplate{e.primary, e.indexes} {
+		for attr, tmpl := range tmpls {
+			av, ok := m[attr]
+			if !ok || av.S == nil {
+				continue
+			}
+
+			if err := tmpl.parse(*av.S, v); err != nil {
+				return fmt.Errorf("failed to parse key attribute '%s': %+v", attr, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//keyTemplate is a parsed composite key template, literals and fields alternate
+//such that segments with an even index are literals and odd ones are fields
+type keyTemplate struct {
+	tmpl     string
+	segments []string
+	fields   [][]int
+}
+
+//parseKeyTemplate parses a template like "GAME#{GameTitle}" for struct type t
+func parseKeyTemplate(t reflect.Type, tmpl string) (*keyTemplate, error) {
+	kt := &keyTemplate{tmpl: tmpl}
+	rest := tmpl
+	for {
+		open := strings.Index(rest, "{")
+		if open < 0 {
+			kt.segments = append(kt.segments, rest)
+			break
+		}
+
+		close := strings.Index(rest[open:], "}")
+		if close < 0 {
+			return nil, fmt.Errorf("unclosed field in template '%s'", tmpl)
+		}
+
+		lit, name := rest[:open], rest[open+1:open+close]
+		if len(kt.segments) > 0 && lit == "" {
+			return nil, fmt.Errorf("fields in template '%s' must be separated by a literal", tmpl)
+		}
+
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return nil, fmt.Errorf("%s has no field '%s'", t, name)
+		}
+
+		switch f.Type.Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return nil, fmt.Errorf("field '%s' must be a string or integer, got: %s", name, f.Type)
+		}
+
+		kt.segments = append(kt.segments, lit, name)
+		kt.fields = append(kt.fields, f.Index)
+		rest = rest[open+close+1:]
+	}
+
+	return kt, nil
+}
+
+//render formats the key from the fields of struct value v
+func (kt *keyTemplate) render(v reflect.Value) (string, error) {
+	b := strings.Builder{}
+	for i, seg := range kt.segments {
+		if i%2 == 0 {
+			b.WriteString(seg)
+			continue
+		}
+
+		f, err := v.FieldByIndexErr(kt.fields[i/2])
+		if err != nil {
+			return "", fmt.Errorf("failed to read field '%s': %+v", seg, err)
+		}
+
+		switch f.Kind() {
+		case reflect.String:
+			b.WriteString(f.String())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			b.WriteString(strconv.FormatUint(f.Uint(), 10))
+		default:
+			b.WriteString(strconv.FormatInt(f.Int(), 10))
+		}
+	}
+
+	return b.String(), nil
+}
+
+//parse splits the key s and sets the fields of struct value v
+func (kt *keyTemplate) parse(s string, v reflect.Value) error {
+	rest := s
+	for i := 0; i < len(kt.segments); i += 2 {
+		if !strings.HasPrefix(rest, kt.segments[i]) {
+			return fmt.Errorf("key '%s' doesn't match template '%s'", s, kt.tmpl)
+		}
+
+		rest = rest[len(kt.segments[i]):]
+		if i+1 >= len(kt.segments) {
+			break
+		}
+
+		//the field value runs up to the next literal, or to the end
+		end := len(rest)
+		if next := kt.segments[i+2]; next != "" {
+			if end = strings.Index(rest, next); end < 0 {
+				return fmt.Errorf("key '%s' doesn't match template '%s'", s, kt.tmpl)
+			}
+		}
+
+		if err := setKeyField(v, kt.fields[i/2], rest[:end]); err != nil {
+			return fmt.Errorf("failed to set field '%s': %+v", kt.segments[i+1], err)
+		}
+
+		rest = rest[end:]
+	}
+
+	if rest != "" {
+		return fmt.Errorf("key '%s' doesn't match template '%s'", s, kt.tmpl)
+	}
+
+	return nil
+}
+
+//setKeyField sets the field at index to the parsed value of s, embedded
+//struct pointers are allocated as needed
+func setKeyField(v reflect.Value, index []int, s string) error {
+	f := v
+	for i, x := range index {
+		if i > 0 {
+			if f.Kind() == reflect.Ptr {
+				if f.IsNil() {
+					f.Set(reflect.New(f.Type().Elem()))
+				}
+
+				f = f.Elem()
+			}
+		}
+
+		f = f.Field(x)
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		f.SetUint(n)
+	default:
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		f.SetInt(n)
+	}
+
+	return nil
+}
+
+//indirectType returns the type that (possibly multiple) pointers point to
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+//indirectValue returns the value that (possibly multiple) pointers point to
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
@@ -0,0 +1,87 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type gameScore struct {
+	GameTitle string `dynamodbav:"-"`
+	UserID    string `dynamodbav:"-"`
+	Round     int    `dynamodbav:"-"`
+	TopScore  int64
+}
+
+func init() {
+	if err := Register(gameScore{}, EntityKeys{
+		Primary: map[string]string{"PK": "GAME#{GameTitle}", "SK": "USER#{UserID}#ROUND#{Round}"},
+		Indexes: map[string]string{"GSI1PK": "USER#{UserID}"},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegistryTemplates(t *testing.T) {
+	r := NewRegistry()
+	equals(t, "invalid template for key attribute 'PK': fields in template '{GameTitle}{UserID}' must be separated by a literal",
+		r.Register(gameScore{}, EntityKeys{Primary: map[string]string{"PK": "{GameTitle}{UserID}"}}).Error())
+	equals(t, "invalid template for key attribute 'PK': dynamo.gameScore has no field 'Foo'",
+		r.Register(gameScore{}, EntityKeys{Primary: map[string]string{"PK": "X#{Foo}"}}).Error())
+
+	pk, err := DefaultRegistry.Key(&gameScore{GameTitle: "Alien Adventure"}, "PK")
+	ok(t, err)
+	equals(t, "GAME#Alien Adventure", pk)
+}
+
+func TestRegistryPutGetQuery(t *testing.T) {
+	var stored map[string]*dynamodb.AttributeValue
+	var key map[string]*dynamodb.AttributeValue
+	db := &fakeDB{
+		putItem: func(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			stored = in.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			key = in.Key
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+		query: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{stored}, Count: aws.Int64(1)}, nil
+		},
+	}
+
+	score := &gameScore{"Alien Adventure", "User-1", 3, 100}
+	ok(t, NewPut("tbl", score).Execute(db))
+	equals(t, map[string]*dynamodb.AttributeValue{
+		"PK":       {S: aws.String("GAME#Alien Adventure")},
+		"SK":       {S: aws.String("USER#User-1#ROUND#3")},
+		"GSI1PK":   {S: aws.String("USER#User-1")},
+		"TopScore": {N: aws.String("100")},
+	}, stored)
+
+	got := &gameScore{}
+	ok(t, NewGet("tbl", gameScore{GameTitle: "Alien Adventure", UserID: "User-1", Round: 3}).Execute(db, got))
+	equals(t, score, got)
+	equals(t, 2, len(key))
+	equals(t, "USER#User-1#ROUND#3", aws.StringValue(key["SK"].S))
+
+	list := []gameScore{}
+	q := NewQuery("tbl", "PK = :pk")
+	q.AddExpressionValue(":pk", aws.StringValue(key["PK"].S))
+	_, err := q.Execute(db, &list)
+	ok(t, err)
+	equals(t, []gameScore{*score}, list)
+}
+
+func TestRegistryNilEntity(t *testing.T) {
+	_, err := MarshalItem((*gameScore)(nil))
+	equals(t, "item is nil", err.Error())
+
+	_, err = MarshalKey((*gameScore)(nil))
+	equals(t, "primary key is nil", err.Error())
+
+	_, err = DefaultRegistry.Key((*gameScore)(nil), "PK")
+	equals(t, "entity *dynamo.gameScore is nil", err.Error())
+}
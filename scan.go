@@ -1,17 +1,21 @@
 package dynamo
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 //Scan holds configuration for a query
 type Scan struct {
 	PagingInput
+	TracingInput
+	MetricsInput
+	EncryptionInput
 	ExpressionHolder
 	dynamodb.ScanInput
 }
@@ -23,50 +27,115 @@ func NewScan(tname string) *Scan {
 	}}
 }
 
+//Clone returns a copy of the scan that can be configured independently, the
+//rate limiter and expression values are shared
+func (inp *Scan) Clone() *Scan {
+	c := *inp
+	c.ExpressionHolder = inp.ExpressionHolder.clone()
+	c.PagingInput = inp.PagingInput.clone()
+	c.ScanInput = *awsutil.CopyOf(&inp.ScanInput).(*dynamodb.ScanInput)
+	return &c
+}
+
 //Execute will scan all items (across partitions) with a background context
-func (inp *Scan) Execute(db dynamodbiface.DynamoDBAPI, items interface{}) (count int64, err error) {
+func (inp *Scan) Execute(db Client, items interface{}) (count int64, err error) {
 	return inp.ExecuteWithContext(aws.BackgroundContext(), db, items)
 }
 
 // ExecuteWithContext reads all items (across partitions) in a table or index
-func (inp *Scan) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDBAPI, items interface{}) (count int64, err error) {
-	if inp.MaxPages == 0 {
-		inp.MaxPages = 1
+func (inp *Scan) ExecuteWithContext(ctx aws.Context, db Client, items interface{}) (count int64, err error) {
+	res, err := inp.execute(ctx, db, items, inp.PagingInput, false, inp.Segment)
+	return res.Count, err
+}
+
+//ExecutePage performs the scan like ExecuteWithContext but also returns the key
+//to resume from (e.g. with SetExclusiveStartKey), next is nil when there are no
+//more items. When MaxItems truncates the last page the key is positioned at the
+//last returned item.
+func (inp *Scan) ExecutePage(ctx aws.Context, db Client, items interface{}) (count int64, next map[string]*dynamodb.AttributeValue, err error) {
+	pi := inp.PagingInput
+	pi.resume = true
+	res, err := inp.execute(ctx, db, items, pi, false, inp.Segment)
+	return res.Count, res.Next.Key, err
+}
+
+//Count counts the items that match the scan (and its filter) across all
+//pages, regardless of MaxPages. It returns the number of matching items and
+//the number of items that were evaluated before the filter was applied. If
+//TotalSegments is configured without a Segment all segments are counted in
+//parallel.
+func (inp *Scan) Count(ctx aws.Context, db Client) (count, scanned int64, err error) {
+	total := aws.Int64Value(inp.TotalSegments)
+	if inp.Segment != nil || total <= 1 {
+		res, err := inp.execute(ctx, db, nil, PagingInput{MaxPages: AllPages, RateLimiter: inp.RateLimiter}, true, inp.Segment)
+		return res.Count, res.ScannedCount, err
 	}
 
-	if len(inp.ExpAttrNames) > 0 {
-		inp.SetExpressionAttributeNames(aws.StringMap(inp.ExpAttrNames))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for seg := int64(0); seg < total; seg++ {
+		wg.Add(1)
+		go func(seg int64) {
+			defer wg.Done()
+			res, serr := inp.execute(ctx, db, nil, PagingInput{MaxPages: AllPages, RateLimiter: inp.RateLimiter}, true, &seg)
+
+			mu.Lock()
+			defer mu.Unlock()
+			count, scanned = count+res.Count, scanned+res.ScannedCount
+			if serr != nil && err == nil {
+				err = fmt.Errorf("failed to count segment %d: %+v", seg, serr)
+				cancel()
+			}
+		}(seg)
 	}
 
-	if len(inp.ExpAttrValues) > 0 {
-		if inp.ExpressionAttributeValues, err = dynamodbattribute.MarshalMap(inp.ExpAttrValues); err != nil {
-			return 0, fmt.Errorf("failed to marshal expression values: %+v", err)
-		}
+	wg.Wait()
+	return count, scanned, err
+}
+
+//execute pages through the scan results of a segment (or the whole table if
+//segment is nil), it counts instead of retrieving the items when count is true
+func (inp *Scan) execute(ctx aws.Context, db Client, items interface{}, pi PagingInput, count bool, segment *int64) (res result, err error) {
+	c := startCall(ctx, inp.Tracer, inp.Metrics, "Scan", inp.TableName, inp.IndexName)
+	defer func() { c.end(err) }()
+
+	names, values, err := inp.expressionAttributes(inp.FilterExpression, inp.ProjectionExpression)
+	if err != nil {
+		return res, err
 	}
 
-	pageNum := 0
-	var lastErr error
-	if err = db.ScanPagesWithContext(ctx, &inp.ScanInput,
-		func(out *dynamodb.ScanOutput, lastPage bool) bool {
-			count += aws.Int64Value(out.Count)
-			pageNum++
-
-			if len(out.Items) > 0 {
-				err = dynamodbattribute.UnmarshalListOfMaps(out.Items, items)
-				if err != nil {
-					lastErr = fmt.Errorf("failed to unmarshal items: %+v", err)
-					return false
-				}
-			}
+	in := inp.ScanInput
+	in.Segment = segment
+	in.ExpressionAttributeNames, in.ExpressionAttributeValues = names, values
 
-			return pageNum < inp.MaxPages
-		}); err != nil {
-		return count, fmt.Errorf("failed to perform request: %+v", err)
+	if pi.RateLimiter != nil && aws.StringValue(in.ReturnConsumedCapacity) != dynamodb.ReturnConsumedCapacityIndexes {
+		in.SetReturnConsumedCapacity(dynamodb.ReturnConsumedCapacityTotal)
 	}
 
-	if lastErr != nil {
-		return count, lastErr
+	if count {
+		in.SetSelect(dynamodb.SelectCount)
+		in.ExpressionAttributeNames = countInput(&in.ProjectionExpression, in.ExpressionAttributeNames, in.FilterExpression)
+	} else {
+		in.ExpressionAttributeNames = projectBoundKeys(&in.ProjectionExpression, in.ExpressionAttributeNames, items, inp.Encryption)
 	}
 
-	return count, nil
+	return paginate(c, pi, cursor{Key: in.ExclusiveStartKey}, func(ctx aws.Context, start cursor, _ int) (*page, error) {
+		in := in
+		in.ExclusiveStartKey = start.Key
+		out, err := db.ScanWithContext(ctx, &in)
+		if err != nil {
+			return nil, err
+		}
+
+		return &page{
+			Items:            out.Items,
+			Count:            aws.Int64Value(out.Count),
+			ScannedCount:     aws.Int64Value(out.ScannedCount),
+			Next:             cursor{Key: out.LastEvaluatedKey},
+			ConsumedCapacity: out.ConsumedCapacity,
+		}, nil
+	}, requestError, inp.Encryption, items)
 }
@@ -0,0 +1,537 @@
+//Package sdkv2 adapts clients of the v2 AWS SDK such that the builders of the
+//dynamo package can execute against them.
+package sdkv2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	dynamodbv1 "github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//API is the part of the v2 DynamoDB client that is adapted, it is
+//implemented by *dynamodb.Client
+type API interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+//Client adapts a v2 client to the interface the builders execute against.
+//Inputs and outputs are converted between the sdk versions, request options
+//of the v1 sdk have no v2 equivalent and are ignored. Legacy parameters
+//(e.g. AttributesToGet, Expected or KeyConditions) are not supported.
+type Client struct {
+	api API
+}
+
+//New adapts the v2 client
+func New(api API) *Client {
+	return &Client{api: api}
+}
+
+//GetItemWithContext gets an item using the v2 client
+func (c *Client) GetItemWithContext(ctx aws.Context, in *dynamodbv1.GetItemInput, opts ...request.Option) (*dynamodbv1.GetItemOutput, error) {
+	if in.AttributesToGet != nil {
+		return nil, errLegacy("AttributesToGet")
+	}
+
+	key, err := MapToV2(in.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert key: %+v", err)
+	}
+
+	out, err := c.api.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:                in.TableName,
+		Key:                      key,
+		ConsistentRead:           in.ConsistentRead,
+		ExpressionAttributeNames: aws.StringValueMap(in.ExpressionAttributeNames),
+		ProjectionExpression:     in.ProjectionExpression,
+		ReturnConsumedCapacity:   types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+	})
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	item, err := MapFromV2(out.Item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert item: %+v", err)
+	}
+
+	return &dynamodbv1.GetItemOutput{Item: item, ConsumedCapacity: capacityFromV2(out.ConsumedCapacity)}, nil
+}
+
+//PutItemWithContext puts an item using the v2 client
+func (c *Client) PutItemWithContext(ctx aws.Context, in *dynamodbv1.PutItemInput, opts ...request.Option) (*dynamodbv1.PutItemOutput, error) {
+	if in.Expected != nil || in.ConditionalOperator != nil {
+		return nil, errLegacy("Expected")
+	}
+
+	item, err := MapToV2(in.Item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert item: %+v", err)
+	}
+
+	vals, err := MapToV2(in.ExpressionAttributeValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert expression values: %+v", err)
+	}
+
+	out, err := c.api.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                           in.TableName,
+		Item:                                item,
+		ConditionExpression:                 in.ConditionExpression,
+		ExpressionAttributeNames:            aws.StringValueMap(in.ExpressionAttributeNames),
+		ExpressionAttributeValues:           vals,
+		ReturnConsumedCapacity:              types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+		ReturnItemCollectionMetrics:         types.ReturnItemCollectionMetrics(aws.StringValue(in.ReturnItemCollectionMetrics)),
+		ReturnValues:                        types.ReturnValue(aws.StringValue(in.ReturnValues)),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(in.ReturnValuesOnConditionCheckFailure)),
+	})
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.PutItemOutput{ConsumedCapacity: capacityFromV2(out.ConsumedCapacity)}
+	if res.Attributes, err = MapFromV2(out.Attributes); err != nil {
+		return nil, fmt.Errorf("failed to convert attributes: %+v", err)
+	}
+
+	if res.ItemCollectionMetrics, err = metricsFromV2(out.ItemCollectionMetrics); err != nil {
+		return nil, fmt.Errorf("failed to convert item collection metrics: %+v", err)
+	}
+
+	return res, nil
+}
+
+//UpdateItemWithContext updates an item using the v2 client
+func (c *Client) UpdateItemWithContext(ctx aws.Context, in *dynamodbv1.UpdateItemInput, opts ...request.Option) (*dynamodbv1.UpdateItemOutput, error) {
+	if in.Expected != nil || in.ConditionalOperator != nil || in.AttributeUpdates != nil {
+		return nil, errLegacy("AttributeUpdates and Expected")
+	}
+
+	key, err := MapToV2(in.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert key: %+v", err)
+	}
+
+	vals, err := MapToV2(in.ExpressionAttributeValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert expression values: %+v", err)
+	}
+
+	out, err := c.api.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                           in.TableName,
+		Key:                                 key,
+		UpdateExpression:                    in.UpdateExpression,
+		ConditionExpression:                 in.ConditionExpression,
+		ExpressionAttributeNames:            aws.StringValueMap(in.ExpressionAttributeNames),
+		ExpressionAttributeValues:           vals,
+		ReturnConsumedCapacity:              types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+		ReturnItemCollectionMetrics:         types.ReturnItemCollectionMetrics(aws.StringValue(in.ReturnItemCollectionMetrics)),
+		ReturnValues:                        types.ReturnValue(aws.StringValue(in.ReturnValues)),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(in.ReturnValuesOnConditionCheckFailure)),
+	})
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.UpdateItemOutput{ConsumedCapacity: capacityFromV2(out.ConsumedCapacity)}
+	if res.Attributes, err = MapFromV2(out.Attributes); err != nil {
+		return nil, fmt.Errorf("failed to convert attributes: %+v", err)
+	}
+
+	if res.ItemCollectionMetrics, err = metricsFromV2(out.ItemCollectionMetrics); err != nil {
+		return nil, fmt.Errorf("failed to convert item collection metrics: %+v", err)
+	}
+
+	return res, nil
+}
+
+//DeleteItemWithContext deletes an item using the v2 client
+func (c *Client) DeleteItemWithContext(ctx aws.Context, in *dynamodbv1.DeleteItemInput, opts ...request.Option) (*dynamodbv1.DeleteItemOutput, error) {
+	if in.Expected != nil || in.ConditionalOperator != nil {
+		return nil, errLegacy("Expected")
+	}
+
+	key, err := MapToV2(in.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert key: %+v", err)
+	}
+
+	vals, err := MapToV2(in.ExpressionAttributeValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert expression values: %+v", err)
+	}
+
+	out, err := c.api.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                           in.TableName,
+		Key:                                 key,
+		ConditionExpression:                 in.ConditionExpression,
+		ExpressionAttributeNames:            aws.StringValueMap(in.ExpressionAttributeNames),
+		ExpressionAttributeValues:           vals,
+		ReturnConsumedCapacity:              types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+		ReturnItemCollectionMetrics:         types.ReturnItemCollectionMetrics(aws.StringValue(in.ReturnItemCollectionMetrics)),
+		ReturnValues:                        types.ReturnValue(aws.StringValue(in.ReturnValues)),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(in.ReturnValuesOnConditionCheckFailure)),
+	})
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.DeleteItemOutput{ConsumedCapacity: capacityFromV2(out.ConsumedCapacity)}
+	if res.Attributes, err = MapFromV2(out.Attributes); err != nil {
+		return nil, fmt.Errorf("failed to convert attributes: %+v", err)
+	}
+
+	if res.ItemCollectionMetrics, err = metricsFromV2(out.ItemCollectionMetrics); err != nil {
+		return nil, fmt.Errorf("failed to convert item collection metrics: %+v", err)
+	}
+
+	return res, nil
+}
+
+//QueryWithContext queries a single page using the v2 client
+func (c *Client) QueryWithContext(ctx aws.Context, in *dynamodbv1.QueryInput, opts ...request.Option) (*dynamodbv1.QueryOutput, error) {
+	if in.AttributesToGet != nil || in.KeyConditions != nil || in.QueryFilter != nil || in.ConditionalOperator != nil {
+		return nil, errLegacy("AttributesToGet, KeyConditions and QueryFilter")
+	}
+
+	start, err := MapToV2(in.ExclusiveStartKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert exclusive start key: %+v", err)
+	}
+
+	vals, err := MapToV2(in.ExpressionAttributeValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert expression values: %+v", err)
+	}
+
+	out, err := c.api.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 in.TableName,
+		IndexName:                 in.IndexName,
+		KeyConditionExpression:    in.KeyConditionExpression,
+		FilterExpression:          in.FilterExpression,
+		ProjectionExpression:      in.ProjectionExpression,
+		ExpressionAttributeNames:  aws.StringValueMap(in.ExpressionAttributeNames),
+		ExpressionAttributeValues: vals,
+		ExclusiveStartKey:         start,
+		ConsistentRead:            in.ConsistentRead,
+		Limit:                     int32Ptr(in.Limit),
+		ScanIndexForward:          in.ScanIndexForward,
+		Select:                    types.Select(aws.StringValue(in.Select)),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+	})
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.QueryOutput{
+		Count:            aws.Int64(int64(out.Count)),
+		ScannedCount:     aws.Int64(int64(out.ScannedCount)),
+		ConsumedCapacity: capacityFromV2(out.ConsumedCapacity),
+	}
+
+	if res.Items, err = listFromV2(out.Items); err != nil {
+		return nil, fmt.Errorf("failed to convert items: %+v", err)
+	}
+
+	if res.LastEvaluatedKey, err = MapFromV2(out.LastEvaluatedKey); err != nil {
+		return nil, fmt.Errorf("failed to convert last evaluated key: %+v", err)
+	}
+
+	return res, nil
+}
+
+//ScanWithContext scans a single page using the v2 client
+func (c *Client) ScanWithContext(ctx aws.Context, in *dynamodbv1.ScanInput, opts ...request.Option) (*dynamodbv1.ScanOutput, error) {
+	if in.AttributesToGet != nil || in.ScanFilter != nil || in.ConditionalOperator != nil {
+		return nil, errLegacy("AttributesToGet and ScanFilter")
+	}
+
+	start, err := MapToV2(in.ExclusiveStartKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert exclusive start key: %+v", err)
+	}
+
+	vals, err := MapToV2(in.ExpressionAttributeValues)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert expression values: %+v", err)
+	}
+
+	out, err := c.api.Scan(ctx, &dynamodb.ScanInput{
+		TableName:                 in.TableName,
+		IndexName:                 in.IndexName,
+		FilterExpression:          in.FilterExpression,
+		ProjectionExpression:      in.ProjectionExpression,
+		ExpressionAttributeNames:  aws.StringValueMap(in.ExpressionAttributeNames),
+		ExpressionAttributeValues: vals,
+		ExclusiveStartKey:         start,
+		ConsistentRead:            in.ConsistentRead,
+		Limit:                     int32Ptr(in.Limit),
+		Segment:                   int32Ptr(in.Segment),
+		TotalSegments:             int32Ptr(in.TotalSegments),
+		Select:                    types.Select(aws.StringValue(in.Select)),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+	})
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.ScanOutput{
+		Count:            aws.Int64(int64(out.Count)),
+		ScannedCount:     aws.Int64(int64(out.ScannedCount)),
+		ConsumedCapacity: capacityFromV2(out.ConsumedCapacity),
+	}
+
+	if res.Items, err = listFromV2(out.Items); err != nil {
+		return nil, fmt.Errorf("failed to convert items: %+v", err)
+	}
+
+	if res.LastEvaluatedKey, err = MapFromV2(out.LastEvaluatedKey); err != nil {
+		return nil, fmt.Errorf("failed to convert last evaluated key: %+v", err)
+	}
+
+	return res, nil
+}
+
+//BatchWriteAPI is the part of the v2 client that batch writes (e.g. of
+//imports) are adapted to, it is implemented by *dynamodb.Client
+type BatchWriteAPI interface {
+	BatchWriteItem(context.Context, *dynamodb.BatchWriteItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+//BatchWriteItemWithContext writes a batch of items using the v2 client, which
+//must implement BatchWriteAPI
+func (c *Client) BatchWriteItemWithContext(ctx aws.Context, in *dynamodbv1.BatchWriteItemInput, opts ...request.Option) (*dynamodbv1.BatchWriteItemOutput, error) {
+	api, ok := c.api.(BatchWriteAPI)
+	if !ok {
+		return nil, errNotImplemented(c.api, "BatchWriteItem")
+	}
+
+	reqs, err := writeRequestsToV2(in.RequestItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert request items: %+v", err)
+	}
+
+	out, err := api.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+		RequestItems:                reqs,
+		ReturnConsumedCapacity:      types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+		ReturnItemCollectionMetrics: types.ReturnItemCollectionMetrics(aws.StringValue(in.ReturnItemCollectionMetrics)),
+	})
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.BatchWriteItemOutput{ConsumedCapacity: capacitiesFromV2(out.ConsumedCapacity)}
+	if res.UnprocessedItems, err = writeRequestsFromV2(out.UnprocessedItems); err != nil {
+		return nil, fmt.Errorf("failed to convert unprocessed items: %+v", err)
+	}
+
+	if res.ItemCollectionMetrics, err = metricsMapFromV2(out.ItemCollectionMetrics); err != nil {
+		return nil, fmt.Errorf("failed to convert item collection metrics: %+v", err)
+	}
+
+	return res, nil
+}
+
+//StatementAPI is the part of the v2 client that PartiQL statements are adapted
+//to, it is implemented by *dynamodb.Client
+type StatementAPI interface {
+	ExecuteStatement(context.Context, *dynamodb.ExecuteStatementInput, ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error)
+	BatchExecuteStatement(context.Context, *dynamodb.BatchExecuteStatementInput, ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error)
+}
+
+//ExecuteStatementWithContext executes a PartiQL statement using the v2
+//client, which must implement StatementAPI
+func (c *Client) ExecuteStatementWithContext(ctx aws.Context, in *dynamodbv1.ExecuteStatementInput, opts ...request.Option) (*dynamodbv1.ExecuteStatementOutput, error) {
+	api, ok := c.api.(StatementAPI)
+	if !ok {
+		return nil, errNotImplemented(c.api, "ExecuteStatement")
+	}
+
+	params, err := listToV2(in.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert parameters: %+v", err)
+	}
+
+	out, err := api.ExecuteStatement(ctx, &dynamodb.ExecuteStatementInput{
+		Statement:                           in.Statement,
+		Parameters:                          params,
+		ConsistentRead:                      in.ConsistentRead,
+		Limit:                               int32Ptr(in.Limit),
+		NextToken:                           in.NextToken,
+		ReturnConsumedCapacity:              types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(in.ReturnValuesOnConditionCheckFailure)),
+	})
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.ExecuteStatementOutput{NextToken: out.NextToken, ConsumedCapacity: capacityFromV2(out.ConsumedCapacity)}
+	if res.Items, err = listFromV2(out.Items); err != nil {
+		return nil, fmt.Errorf("failed to convert items: %+v", err)
+	}
+
+	if res.LastEvaluatedKey, err = MapFromV2(out.LastEvaluatedKey); err != nil {
+		return nil, fmt.Errorf("failed to convert last evaluated key: %+v", err)
+	}
+
+	return res, nil
+}
+
+//BatchExecuteStatementWithContext executes a batch of PartiQL statements using
+//the v2 client, which must implement StatementAPI
+func (c *Client) BatchExecuteStatementWithContext(ctx aws.Context, in *dynamodbv1.BatchExecuteStatementInput, opts ...request.Option) (*dynamodbv1.BatchExecuteStatementOutput, error) {
+	api, ok := c.api.(StatementAPI)
+	if !ok {
+		return nil, errNotImplemented(c.api, "BatchExecuteStatement")
+	}
+
+	v2 := &dynamodb.BatchExecuteStatementInput{
+		ReturnConsumedCapacity: types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+	}
+
+	for i, st := range in.Statements {
+		params, err := listToV2(st.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert parameters of statement %d: %+v", i, err)
+		}
+
+		v2.Statements = append(v2.Statements, types.BatchStatementRequest{
+			Statement:                           st.Statement,
+			Parameters:                          params,
+			ConsistentRead:                      st.ConsistentRead,
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(st.ReturnValuesOnConditionCheckFailure)),
+		})
+	}
+
+	out, err := api.BatchExecuteStatement(ctx, v2)
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.BatchExecuteStatementOutput{ConsumedCapacity: capacitiesFromV2(out.ConsumedCapacity)}
+	for i, resp := range out.Responses {
+		r := &dynamodbv1.BatchStatementResponse{TableName: resp.TableName}
+		if r.Item, err = MapFromV2(resp.Item); err != nil {
+			return nil, fmt.Errorf("failed to convert item of statement %d: %+v", i, err)
+		}
+
+		if resp.Error != nil {
+			r.Error = &dynamodbv1.BatchStatementError{Code: aws.String(string(resp.Error.Code)), Message: resp.Error.Message}
+			if r.Error.Item, err = MapFromV2(resp.Error.Item); err != nil {
+				return nil, fmt.Errorf("failed to convert item of statement %d: %+v", i, err)
+			}
+		}
+
+		res.Responses = append(res.Responses, r)
+	}
+
+	return res, nil
+}
+
+//TransactAPI is the part of the v2 client that transactions (e.g. of chunked
+//items) are adapted to, it is implemented by *dynamodb.Client
+type TransactAPI interface {
+	TransactGetItems(context.Context, *dynamodb.TransactGetItemsInput, ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error)
+	TransactWriteItems(context.Context, *dynamodb.TransactWriteItemsInput, ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+//TransactGetItemsWithContext reads items in a transaction using the v2 client,
+//which must implement TransactAPI
+func (c *Client) TransactGetItemsWithContext(ctx aws.Context, in *dynamodbv1.TransactGetItemsInput, opts ...request.Option) (*dynamodbv1.TransactGetItemsOutput, error) {
+	api, ok := c.api.(TransactAPI)
+	if !ok {
+		return nil, errNotImplemented(c.api, "TransactGetItems")
+	}
+
+	v2 := &dynamodb.TransactGetItemsInput{
+		ReturnConsumedCapacity: types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+	}
+
+	for i, it := range in.TransactItems {
+		key, err := MapToV2(it.Get.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert key of item %d: %+v", i, err)
+		}
+
+		v2.TransactItems = append(v2.TransactItems, types.TransactGetItem{Get: &types.Get{
+			TableName:                it.Get.TableName,
+			Key:                      key,
+			ExpressionAttributeNames: aws.StringValueMap(it.Get.ExpressionAttributeNames),
+			ProjectionExpression:     it.Get.ProjectionExpression,
+		}})
+	}
+
+	out, err := api.TransactGetItems(ctx, v2)
+	if err != nil {
+		return nil, errFromV2(err)
+	}
+
+	res := &dynamodbv1.TransactGetItemsOutput{ConsumedCapacity: capacitiesFromV2(out.ConsumedCapacity)}
+	for i, resp := range out.Responses {
+		item, err := MapFromV2(resp.Item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert item %d: %+v", i, err)
+		}
+
+		res.Responses = append(res.Responses, &dynamodbv1.ItemResponse{Item: item})
+	}
+
+	return res, nil
+}
+
+//TransactWriteItemsWithContext writes items in a transaction using the v2
+//client, which must implement TransactAPI. A canceled transaction returns the
+//v1 TransactionCanceledException such that its reasons can be inspected.
+func (c *Client) TransactWriteItemsWithContext(ctx aws.Context, in *dynamodbv1.TransactWriteItemsInput, opts ...request.Option) (*dynamodbv1.TransactWriteItemsOutput, error) {
+	api, ok := c.api.(TransactAPI)
+	if !ok {
+		return nil, errNotImplemented(c.api, "TransactWriteItems")
+	}
+
+	v2 := &dynamodb.TransactWriteItemsInput{
+		ClientRequestToken:          in.ClientRequestToken,
+		ReturnConsumedCapacity:      types.ReturnConsumedCapacity(aws.StringValue(in.ReturnConsumedCapacity)),
+		ReturnItemCollectionMetrics: types.ReturnItemCollectionMetrics(aws.StringValue(in.ReturnItemCollectionMetrics)),
+	}
+
+	for i, it := range in.TransactItems {
+		wi, err := transactWriteToV2(it)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert item %d: %+v", i, err)
+		}
+
+		v2.TransactItems = append(v2.TransactItems, wi)
+	}
+
+	out, err := api.TransactWriteItems(ctx, v2)
+	if err != nil {
+		return nil, canceledFromV2(err)
+	}
+
+	res := &dynamodbv1.TransactWriteItemsOutput{ConsumedCapacity: capacitiesFromV2(out.ConsumedCapacity)}
+	if res.ItemCollectionMetrics, err = metricsMapFromV2(out.ItemCollectionMetrics); err != nil {
+		return nil, fmt.Errorf("failed to convert item collection metrics: %+v", err)
+	}
+
+	return res, nil
+}
+
+//errLegacy is returned when legacy parameters are used that are not supported
+func errLegacy(params string) error {
+	return fmt.Errorf("legacy parameters (%s) are not supported by the v2 adapter, use expressions instead", params)
+}
+
+//errNotImplemented is returned when the v2 client doesn't implement an operation
+func errNotImplemented(api API, op string) error {
+	return fmt.Errorf("v2 client %T doesn't implement %s", api, op)
+}
@@ -0,0 +1,228 @@
+package sdkv2
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/advanderveer/go-dynamo"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go/aws"
+	dynamodbv1 "github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/smithy-go"
+)
+
+var errScoreExists = errors.New("score exists")
+
+type scorePK struct {
+	Game string
+	User string
+}
+
+type score struct {
+	scorePK
+	Top   int64
+	Tags  []string `dynamodbav:",stringset"`
+	Extra map[string]interface{}
+}
+
+// fakeAPI stores a single item and serves it through the v2 api
+type fakeAPI struct {
+	API
+	item map[string]types.AttributeValue
+}
+
+func (f *fakeAPI) PutItem(ctx context.Context, in *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if in.ConditionExpression != nil && f.item != nil {
+		return nil, &smithy.GenericAPIError{Code: "ConditionalCheckFailedException", Message: "item exists"}
+	}
+
+	f.item = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeAPI) GetItem(ctx context.Context, in *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: f.item, ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)}}, nil
+}
+
+func (f *fakeAPI) Query(ctx context.Context, in *dynamodb.QueryInput, opts ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if in.ExpressionAttributeValues[":game"].(*types.AttributeValueMemberS).Value != "Alien Adventure" {
+		return &dynamodb.QueryOutput{}, nil
+	}
+
+	return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{f.item}, Count: 1, ScannedCount: 1}, nil
+}
+
+func TestBuildersOnV2Client(t *testing.T) {
+	api := &fakeAPI{}
+	db := New(api)
+
+	s1 := &score{scorePK{"Alien Adventure", "User-1"}, 100, []string{"a", "b"}, map[string]interface{}{"x": true, "y": nil}}
+	ok(t, dynamo.NewPut("scores", s1).Execute(db))
+
+	put := dynamo.NewPut("scores", s1)
+	put.SetConditionExpression("attribute_not_exists(Game)")
+	put.SetConditionError(errScoreExists)
+	equals(t, errScoreExists, put.Execute(db))
+
+	s2 := &score{}
+	ok(t, dynamo.NewGet("scores", s1.scorePK).Execute(db, s2))
+	equals(t, s1, s2)
+
+	q := dynamo.NewQuery("scores", "Game = :game")
+	q.AddExpressionValue(":game", "Alien Adventure")
+	list := []*score{}
+	n, err := q.Execute(db, &list)
+	ok(t, err)
+	equals(t, int64(1), n)
+	equals(t, []*score{s1}, list)
+}
+
+func TestAttributeValueRoundTrip(t *testing.T) {
+	av := &dynamodbv1.AttributeValue{M: map[string]*dynamodbv1.AttributeValue{
+		"s":  {S: aws.String("a")},
+		"n":  {N: aws.String("1.5")},
+		"b":  {B: []byte("x")},
+		"ok": {BOOL: aws.Bool(true)},
+		"0":  {NULL: aws.Bool(true)},
+		"ss": {SS: aws.StringSlice([]string{"a"})},
+		"ns": {NS: aws.StringSlice([]string{"1"})},
+		"bs": {BS: [][]byte{[]byte("y")}},
+		"l":  {L: []*dynamodbv1.AttributeValue{{S: aws.String("b")}}},
+	}}
+
+	v2, err := ToV2(av)
+	ok(t, err)
+	v1, err := FromV2(v2)
+	ok(t, err)
+	equals(t, av, v1)
+}
+
+// batchAPI serves batch writes through the v2 api
+type batchAPI struct {
+	fakeAPI
+	written []map[string]types.AttributeValue
+}
+
+func (f *batchAPI) BatchWriteItem(ctx context.Context, in *dynamodb.BatchWriteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	reqs := in.RequestItems["scores"]
+	for _, req := range reqs[:1] {
+		f.written = append(f.written, req.PutRequest.Item)
+	}
+
+	out := &dynamodb.BatchWriteItemOutput{}
+	if len(reqs) > 1 {
+		out.UnprocessedItems = map[string][]types.WriteRequest{"scores": reqs[1:]}
+	}
+
+	return out, nil
+}
+
+func TestImportOnV2Client(t *testing.T) {
+	api := &batchAPI{}
+	im := dynamo.NewImporter("scores")
+	n, err := im.Execute(context.Background(), New(api), strings.NewReader("{\"Game\":{\"S\":\"a\"}}\n{\"Game\":{\"S\":\"b\"}}\n"))
+	ok(t, err)
+	equals(t, int64(2), n)
+	equals(t, []map[string]types.AttributeValue{
+		{"Game": &types.AttributeValueMemberS{Value: "a"}},
+		{"Game": &types.AttributeValueMemberS{Value: "b"}},
+	}, api.written)
+
+	_, err = New(&fakeAPI{}).BatchWriteItemWithContext(context.Background(), &dynamodbv1.BatchWriteItemInput{})
+	equals(t, "v2 client *sdkv2.fakeAPI doesn't implement BatchWriteItem", err.Error())
+}
+
+// statementAPI serves statements through the v2 api
+type statementAPI struct {
+	fakeAPI
+	params []types.AttributeValue
+}
+
+func (f *statementAPI) ExecuteStatement(ctx context.Context, in *dynamodb.ExecuteStatementInput, opts ...func(*dynamodb.Options)) (*dynamodb.ExecuteStatementOutput, error) {
+	f.params = in.Parameters
+	return &dynamodb.ExecuteStatementOutput{Items: []map[string]types.AttributeValue{
+		{"Game": &types.AttributeValueMemberS{Value: "Alien Adventure"}, "Top": &types.AttributeValueMemberN{Value: "100"}},
+	}}, nil
+}
+
+func (f *statementAPI) BatchExecuteStatement(ctx context.Context, in *dynamodb.BatchExecuteStatementInput, opts ...func(*dynamodb.Options)) (*dynamodb.BatchExecuteStatementOutput, error) {
+	return &dynamodb.BatchExecuteStatementOutput{Responses: []types.BatchStatementResponse{
+		{},
+		{Error: &types.BatchStatementError{Code: types.BatchStatementErrorCodeEnumConditionalCheckFailed, Message: aws.String("failed")}},
+	}}, nil
+}
+
+func TestStatementsOnV2Client(t *testing.T) {
+	api := &statementAPI{}
+	var list []*score
+	n, err := dynamo.NewStatement(`SELECT * FROM "scores" WHERE Game = ?`, "Alien Adventure").Execute(New(api), &list)
+	ok(t, err)
+	equals(t, int64(1), n)
+	equals(t, []*score{{scorePK: scorePK{Game: "Alien Adventure"}, Top: 100}}, list)
+	equals(t, []types.AttributeValue{&types.AttributeValueMemberS{Value: "Alien Adventure"}}, api.params)
+
+	batch := dynamo.NewBatchStatement()
+	batch.Add(`INSERT INTO "scores" VALUE {'Game': ?}`, "a").Add(`INSERT INTO "scores" VALUE {'Game': ?}`, "b")
+	batch.SetConditionError(errScoreExists)
+	errs, err := batch.Execute(New(api), nil)
+	ok(t, err)
+	equals(t, []error{nil, errScoreExists}, errs)
+}
+
+// transactAPI serves the transactions of chunked items through the v2 api, the
+// manifest is stored as the single item and the chunks by their user
+type transactAPI struct {
+	fakeAPI
+	chunks map[string]map[string]types.AttributeValue
+}
+
+func (f *transactAPI) TransactWriteItems(ctx context.Context, in *dynamodb.TransactWriteItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if strings.HasPrefix(aws.StringValue(in.TransactItems[0].Put.ConditionExpression), "(attribute_not_exists(Game))") {
+		return nil, &types.TransactionCanceledException{Message: aws.String("Transaction cancelled"), CancellationReasons: []types.CancellationReason{
+			{Code: aws.String("ConditionalCheckFailed")}, {Code: aws.String("None")},
+		}}
+	}
+
+	f.item = in.TransactItems[0].Put.Item
+	for _, it := range in.TransactItems[1:] {
+		f.chunks[it.Put.Item["User"].(*types.AttributeValueMemberS).Value] = it.Put.Item
+	}
+
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (f *transactAPI) TransactGetItems(ctx context.Context, in *dynamodb.TransactGetItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	out := &dynamodb.TransactGetItemsOutput{Responses: []types.ItemResponse{{Item: f.item}}}
+	for _, it := range in.TransactItems[1:] {
+		out.Responses = append(out.Responses, types.ItemResponse{Item: f.chunks[it.Get.Key["User"].(*types.AttributeValueMemberS).Value]})
+	}
+
+	return out, nil
+}
+
+func TestChunkingOnV2Client(t *testing.T) {
+	api := &transactAPI{chunks: map[string]map[string]types.AttributeValue{}}
+	ch := dynamo.NewChunking("Game", "User")
+
+	s1 := &score{scorePK{"Alien Adventure", "User-1"}, 100, nil, map[string]interface{}{"blob": strings.Repeat("x", 500*1024)}}
+	put := dynamo.NewPut("scores", s1)
+	put.SetChunking(ch)
+	ok(t, put.Execute(New(api)))
+	equals(t, 2, len(api.chunks))
+
+	s2 := &score{}
+	get := dynamo.NewGet("scores", s1.scorePK)
+	get.SetChunking(ch)
+	ok(t, get.Execute(New(api), s2))
+	equals(t, s1, s2)
+
+	put.SetConditionExpression("attribute_not_exists(Game)")
+	put.SetConditionError(errScoreExists)
+	equals(t, errScoreExists, put.Execute(New(api)))
+
+	_, err := New(&fakeAPI{}).TransactGetItemsWithContext(context.Background(), &dynamodbv1.TransactGetItemsInput{})
+	equals(t, "v2 client *sdkv2.fakeAPI doesn't implement TransactGetItems", err.Error())
+}
@@ -0,0 +1,443 @@
+package sdkv2
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/smithy-go"
+)
+
+//ToV2 converts an attribute value of the v1 sdk into one of the v2 sdk
+func ToV2(av *dynamodb.AttributeValue) (types.AttributeValue, error) {
+	switch {
+	case av == nil:
+		return nil, nil
+	case av.S != nil:
+		return &types.AttributeValueMemberS{Value: *av.S}, nil
+	case av.N != nil:
+		return &types.AttributeValueMemberN{Value: *av.N}, nil
+	case av.B != nil:
+		return &types.AttributeValueMemberB{Value: av.B}, nil
+	case av.BOOL != nil:
+		return &types.AttributeValueMemberBOOL{Value: *av.BOOL}, nil
+	case av.NULL != nil:
+		return &types.AttributeValueMemberNULL{Value: *av.NULL}, nil
+	case av.SS != nil:
+		return &types.AttributeValueMemberSS{Value: aws.StringValueSlice(av.SS)}, nil
+	case av.NS != nil:
+		return &types.AttributeValueMemberNS{Value: aws.StringValueSlice(av.NS)}, nil
+	case av.BS != nil:
+		return &types.AttributeValueMemberBS{Value: av.BS}, nil
+	case av.M != nil:
+		m, err := MapToV2(av.M)
+		if err != nil {
+			return nil, err
+		}
+
+		return &types.AttributeValueMemberM{Value: m}, nil
+	case av.L != nil:
+		l := make([]types.AttributeValue, 0, len(av.L))
+		for _, elem := range av.L {
+			v, err := ToV2(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			l = append(l, v)
+		}
+
+		return &types.AttributeValueMemberL{Value: l}, nil
+	default:
+		return nil, fmt.Errorf("attribute value has no data type set")
+	}
+}
+
+//FromV2 converts an attribute value of the v2 sdk into one of the v1 sdk
+func FromV2(av types.AttributeValue) (*dynamodb.AttributeValue, error) {
+	switch v := av.(type) {
+	case nil:
+		return nil, nil
+	case *types.AttributeValueMemberS:
+		return &dynamodb.AttributeValue{S: aws.String(v.Value)}, nil
+	case *types.AttributeValueMemberN:
+		return &dynamodb.AttributeValue{N: aws.String(v.Value)}, nil
+	case *types.AttributeValueMemberB:
+		return &dynamodb.AttributeValue{B: v.Value}, nil
+	case *types.AttributeValueMemberBOOL:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(v.Value)}, nil
+	case *types.AttributeValueMemberNULL:
+		return &dynamodb.AttributeValue{NULL: aws.Bool(v.Value)}, nil
+	case *types.AttributeValueMemberSS:
+		return &dynamodb.AttributeValue{SS: aws.StringSlice(v.Value)}, nil
+	case *types.AttributeValueMemberNS:
+		return &dynamodb.AttributeValue{NS: aws.StringSlice(v.Value)}, nil
+	case *types.AttributeValueMemberBS:
+		return &dynamodb.AttributeValue{BS: v.Value}, nil
+	case *types.AttributeValueMemberM:
+		m, err := MapFromV2(v.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return &dynamodb.AttributeValue{M: m}, nil
+	case *types.AttributeValueMemberL:
+		l := make([]*dynamodb.AttributeValue, 0, len(v.Value))
+		for _, elem := range v.Value {
+			av, err := FromV2(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			l = append(l, av)
+		}
+
+		return &dynamodb.AttributeValue{L: l}, nil
+	default:
+		return nil, fmt.Errorf("unsupported attribute value type %T", av)
+	}
+}
+
+//MapToV2 converts an attribute value map of the v1 sdk into one of the v2 sdk
+func MapToV2(m map[string]*dynamodb.AttributeValue) (map[string]types.AttributeValue, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	out := make(map[string]types.AttributeValue, len(m))
+	for k, av := range m {
+		v, err := ToV2(av)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert attribute '%s': %+v", k, err)
+		}
+
+		out[k] = v
+	}
+
+	return out, nil
+}
+
+//MapFromV2 converts an attribute value map of the v2 sdk into one of the v1 sdk
+func MapFromV2(m map[string]types.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	out := make(map[string]*dynamodb.AttributeValue, len(m))
+	for k, av := range m {
+		v, err := FromV2(av)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert attribute '%s': %+v", k, err)
+		}
+
+		out[k] = v
+	}
+
+	return out, nil
+}
+
+//listToV2 converts a list of attribute values, e.g. statement parameters
+func listToV2(l []*dynamodb.AttributeValue) ([]types.AttributeValue, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	out := make([]types.AttributeValue, 0, len(l))
+	for i, av := range l {
+		v, err := ToV2(av)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert value %d: %+v", i, err)
+		}
+
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+//listFromV2 converts a list of attribute value maps of the v2 sdk
+func listFromV2(l []map[string]types.AttributeValue) ([]map[string]*dynamodb.AttributeValue, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	out := make([]map[string]*dynamodb.AttributeValue, 0, len(l))
+	for _, m := range l {
+		v, err := MapFromV2(m)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+//capacityFromV2 converts consumed capacity of the v2 sdk
+func capacityFromV2(cc *types.ConsumedCapacity) *dynamodb.ConsumedCapacity {
+	if cc == nil {
+		return nil
+	}
+
+	out := &dynamodb.ConsumedCapacity{
+		TableName:          cc.TableName,
+		CapacityUnits:      cc.CapacityUnits,
+		ReadCapacityUnits:  cc.ReadCapacityUnits,
+		WriteCapacityUnits: cc.WriteCapacityUnits,
+		Table:              capacityUnitsFromV2(cc.Table),
+	}
+
+	for name, c := range cc.GlobalSecondaryIndexes {
+		c := c
+		if out.GlobalSecondaryIndexes == nil {
+			out.GlobalSecondaryIndexes = map[string]*dynamodb.Capacity{}
+		}
+
+		out.GlobalSecondaryIndexes[name] = capacityUnitsFromV2(&c)
+	}
+
+	for name, c := range cc.LocalSecondaryIndexes {
+		c := c
+		if out.LocalSecondaryIndexes == nil {
+			out.LocalSecondaryIndexes = map[string]*dynamodb.Capacity{}
+		}
+
+		out.LocalSecondaryIndexes[name] = capacityUnitsFromV2(&c)
+	}
+
+	return out
+}
+
+func capacityUnitsFromV2(c *types.Capacity) *dynamodb.Capacity {
+	if c == nil {
+		return nil
+	}
+
+	return &dynamodb.Capacity{
+		CapacityUnits:      c.CapacityUnits,
+		ReadCapacityUnits:  c.ReadCapacityUnits,
+		WriteCapacityUnits: c.WriteCapacityUnits,
+	}
+}
+
+//capacitiesFromV2 converts the consumed capacity of multiple tables
+func capacitiesFromV2(l []types.ConsumedCapacity) (out []*dynamodb.ConsumedCapacity) {
+	for i := range l {
+		out = append(out, capacityFromV2(&l[i]))
+	}
+
+	return out
+}
+
+//metricsFromV2 converts item collection metrics of the v2 sdk
+func metricsFromV2(icm *types.ItemCollectionMetrics) (*dynamodb.ItemCollectionMetrics, error) {
+	if icm == nil {
+		return nil, nil
+	}
+
+	key, err := MapFromV2(icm.ItemCollectionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.ItemCollectionMetrics{
+		ItemCollectionKey:   key,
+		SizeEstimateRangeGB: aws.Float64Slice(icm.SizeEstimateRangeGB),
+	}, nil
+}
+
+//int32Ptr converts an optional v1 integer into an optional v2 integer
+func int32Ptr(v *int64) *int32 {
+	if v == nil {
+		return nil
+	}
+
+	n := int32(*v)
+	return &n
+}
+
+//errFromV2 converts errors returned by the v2 sdk into the awserr.Error that
+//is returned by the v1 sdk, such that the error codes can be inspected as usual
+func errFromV2(err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	return awserr.New(apiErr.ErrorCode(), apiErr.ErrorMessage(), err)
+}
+
+//metricsMapFromV2 converts the item collection metrics of multiple tables
+func metricsMapFromV2(m map[string][]types.ItemCollectionMetrics) (map[string][]*dynamodb.ItemCollectionMetrics, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	out := make(map[string][]*dynamodb.ItemCollectionMetrics, len(m))
+	for table, l := range m {
+		for i := range l {
+			icm, err := metricsFromV2(&l[i])
+			if err != nil {
+				return nil, err
+			}
+
+			out[table] = append(out[table], icm)
+		}
+	}
+
+	return out, nil
+}
+
+//writeRequestsToV2 converts the write requests of a batch per table
+func writeRequestsToV2(m map[string][]*dynamodb.WriteRequest) (map[string][]types.WriteRequest, error) {
+	out := make(map[string][]types.WriteRequest, len(m))
+	for table, reqs := range m {
+		for _, req := range reqs {
+			var v2 types.WriteRequest
+			switch {
+			case req.PutRequest != nil:
+				item, err := MapToV2(req.PutRequest.Item)
+				if err != nil {
+					return nil, err
+				}
+
+				v2.PutRequest = &types.PutRequest{Item: item}
+			case req.DeleteRequest != nil:
+				key, err := MapToV2(req.DeleteRequest.Key)
+				if err != nil {
+					return nil, err
+				}
+
+				v2.DeleteRequest = &types.DeleteRequest{Key: key}
+			}
+
+			out[table] = append(out[table], v2)
+		}
+	}
+
+	return out, nil
+}
+
+//writeRequestsFromV2 converts the write requests of a batch per table
+func writeRequestsFromV2(m map[string][]types.WriteRequest) (map[string][]*dynamodb.WriteRequest, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+
+	out := make(map[string][]*dynamodb.WriteRequest, len(m))
+	for table, reqs := range m {
+		for _, req := range reqs {
+			v1 := &dynamodb.WriteRequest{}
+			switch {
+			case req.PutRequest != nil:
+				item, err := MapFromV2(req.PutRequest.Item)
+				if err != nil {
+					return nil, err
+				}
+
+				v1.PutRequest = &dynamodb.PutRequest{Item: item}
+			case req.DeleteRequest != nil:
+				key, err := MapFromV2(req.DeleteRequest.Key)
+				if err != nil {
+					return nil, err
+				}
+
+				v1.DeleteRequest = &dynamodb.DeleteRequest{Key: key}
+			}
+
+			out[table] = append(out[table], v1)
+		}
+	}
+
+	return out, nil
+}
+
+//transactWriteToV2 converts a single write of a transaction
+func transactWriteToV2(it *dynamodb.TransactWriteItem) (wi types.TransactWriteItem, err error) {
+	switch {
+	case it.ConditionCheck != nil:
+		cc := it.ConditionCheck
+		wi.ConditionCheck = &types.ConditionCheck{
+			TableName:                           cc.TableName,
+			ConditionExpression:                 cc.ConditionExpression,
+			ExpressionAttributeNames:            aws.StringValueMap(cc.ExpressionAttributeNames),
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(cc.ReturnValuesOnConditionCheckFailure)),
+		}
+
+		if wi.ConditionCheck.Key, err = MapToV2(cc.Key); err != nil {
+			return wi, err
+		}
+
+		wi.ConditionCheck.ExpressionAttributeValues, err = MapToV2(cc.ExpressionAttributeValues)
+	case it.Put != nil:
+		put := it.Put
+		wi.Put = &types.Put{
+			TableName:                           put.TableName,
+			ConditionExpression:                 put.ConditionExpression,
+			ExpressionAttributeNames:            aws.StringValueMap(put.ExpressionAttributeNames),
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(put.ReturnValuesOnConditionCheckFailure)),
+		}
+
+		if wi.Put.Item, err = MapToV2(put.Item); err != nil {
+			return wi, err
+		}
+
+		wi.Put.ExpressionAttributeValues, err = MapToV2(put.ExpressionAttributeValues)
+	case it.Delete != nil:
+		del := it.Delete
+		wi.Delete = &types.Delete{
+			TableName:                           del.TableName,
+			ConditionExpression:                 del.ConditionExpression,
+			ExpressionAttributeNames:            aws.StringValueMap(del.ExpressionAttributeNames),
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(del.ReturnValuesOnConditionCheckFailure)),
+		}
+
+		if wi.Delete.Key, err = MapToV2(del.Key); err != nil {
+			return wi, err
+		}
+
+		wi.Delete.ExpressionAttributeValues, err = MapToV2(del.ExpressionAttributeValues)
+	case it.Update != nil:
+		upd := it.Update
+		wi.Update = &types.Update{
+			TableName:                           upd.TableName,
+			UpdateExpression:                    upd.UpdateExpression,
+			ConditionExpression:                 upd.ConditionExpression,
+			ExpressionAttributeNames:            aws.StringValueMap(upd.ExpressionAttributeNames),
+			ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailure(aws.StringValue(upd.ReturnValuesOnConditionCheckFailure)),
+		}
+
+		if wi.Update.Key, err = MapToV2(upd.Key); err != nil {
+			return wi, err
+		}
+
+		wi.Update.ExpressionAttributeValues, err = MapToV2(upd.ExpressionAttributeValues)
+	}
+
+	return wi, err
+}
+
+//canceledFromV2 converts a canceled transaction into the v1 exception that
+//holds the cancellation reasons, other errors are converted by errFromV2
+func canceledFromV2(err error) error {
+	var tce *types.TransactionCanceledException
+	if !errors.As(err, &tce) {
+		return errFromV2(err)
+	}
+
+	v1 := &dynamodb.TransactionCanceledException{Message_: aws.String(tce.ErrorMessage())}
+	for _, r := range tce.CancellationReasons {
+		item, cerr := MapFromV2(r.Item)
+		if cerr != nil {
+			return fmt.Errorf("failed to convert cancellation reason: %+v", cerr)
+		}
+
+		v1.CancellationReasons = append(v1.CancellationReasons, &dynamodb.CancellationReason{Code: r.Code, Message: r.Message, Item: item})
+	}
+
+	return v1
+}
@@ -0,0 +1,268 @@
+package dynamo
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//maxBatchStatements is the number of statements a batch may hold
+const maxBatchStatements = 25
+
+//StatementClient is the part of the DynamoDB API that executes PartiQL
+//statements, it is satisfied by the dynamodbiface.DynamoDBAPI of the v1 SDK
+type StatementClient interface {
+	ExecuteStatementWithContext(aws.Context, *dynamodb.ExecuteStatementInput, ...request.Option) (*dynamodb.ExecuteStatementOutput, error)
+	BatchExecuteStatementWithContext(aws.Context, *dynamodb.BatchExecuteStatementInput, ...request.Option) (*dynamodb.BatchExecuteStatementOutput, error)
+}
+
+//Statement holds configuration for executing a PartiQL statement, e.g.:
+//
+//	st := NewStatement(`SELECT * FROM "scores" WHERE GameTitle = ? AND TopScore > ?`, title, 100)
+type Statement struct {
+	ConditionInput
+	TracingInput
+	MetricsInput
+	PagingInput
+	EncryptionInput
+	dynamodb.ExecuteStatementInput
+	Params []interface{}
+}
+
+//NewStatement prepares a statement whose '?' parameters are bound to params in
+//order, params are marshalled like expression values
+func NewStatement(stmt string, params ...interface{}) *Statement {
+	return &Statement{ExecuteStatementInput: dynamodb.ExecuteStatementInput{
+		Statement: aws.String(stmt),
+	}, Params: params}
+}
+
+//Execute executes the statement with a background context
+func (inp *Statement) Execute(db StatementClient, items interface{}) (count int64, err error) {
+	return inp.ExecuteWithContext(aws.BackgroundContext(), db, items)
+}
+
+//ExecuteWithContext executes the statement and decodes the items it returns
+//across the pages it is configured to fetch (see SetMaxPages and SetMaxItems)
+//into items, which may be nil for statements that don't return items
+func (inp *Statement) ExecuteWithContext(ctx aws.Context, db StatementClient, items interface{}) (count int64, err error) {
+	count, _, err = inp.ExecutePage(ctx, db, items)
+	return count, err
+}
+
+//ExecutePage executes the statement like ExecuteWithContext but also returns
+//the token to resume from (e.g. with SetNextToken), next is nil when there are
+//no more items. With MaxItems the limit of the last request is lowered to the
+//items that remain, such that the token never skips items.
+func (inp *Statement) ExecutePage(ctx aws.Context, db StatementClient, items interface{}) (count int64, next *string, err error) {
+	table, index := statementTable(aws.StringValue(inp.Statement))
+	c := startCall(ctx, inp.Tracer, inp.Metrics, "ExecuteStatement", &table, &index)
+	defer func() { c.end(err) }()
+
+	in := inp.ExecuteStatementInput
+	if in.Parameters, err = marshalParams(inp.Params); err != nil {
+		return 0, nil, err
+	}
+
+	if inp.RateLimiter != nil && aws.StringValue(in.ReturnConsumedCapacity) != dynamodb.ReturnConsumedCapacityIndexes {
+		in.SetReturnConsumedCapacity(dynamodb.ReturnConsumedCapacityTotal)
+	}
+
+	res, err := paginate(c, inp.PagingInput, cursor{Token: in.NextToken}, func(ctx aws.Context, start cursor, remaining int) (*page, error) {
+		in := in
+		in.NextToken = start.Token
+		if remaining > 0 && (in.Limit == nil || aws.Int64Value(in.Limit) > int64(remaining)) {
+			in.SetLimit(int64(remaining))
+		}
+
+		out, err := db.ExecuteStatementWithContext(ctx, &in)
+		if err != nil {
+			return nil, err
+		}
+
+		n := int64(len(out.Items))
+		return &page{
+			Items:            out.Items,
+			Count:            n,
+			ScannedCount:     n,
+			Next:             cursor{Token: out.NextToken},
+			ConsumedCapacity: out.ConsumedCapacity,
+		}, nil
+	}, inp.mapError, inp.Encryption, items)
+	if res.Next.done() {
+		return res.Count, nil, err
+	}
+
+	return res.Count, res.Next.Token, err
+}
+
+//mapError returns the condition error for failed conditions
+func (inp *Statement) mapError(err error) error {
+	aerr, ok := err.(awserr.Error)
+	if !ok || (aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException && aerr.Code() != dynamodb.ErrCodeDuplicateItemException) {
+		return fmt.Errorf("failed to perform request: %+v", err)
+	}
+
+	if inp.ConditionError != nil {
+		return inp.ConditionError
+	}
+
+	return err
+}
+
+//BatchStatement holds configuration for executing up to 25 PartiQL statements
+//in a single request, they either all read or all write
+type BatchStatement struct {
+	ConditionInput
+	TracingInput
+	MetricsInput
+	EncryptionInput
+	dynamodb.BatchExecuteStatementInput
+	stmts []*Statement
+}
+
+//NewBatchStatement prepares an empty batch
+func NewBatchStatement() *BatchStatement {
+	return &BatchStatement{}
+}
+
+//Add adds a statement whose '?' parameters are bound to params in order
+func (inp *BatchStatement) Add(stmt string, params ...interface{}) *BatchStatement {
+	inp.stmts = append(inp.stmts, NewStatement(stmt, params...))
+	return inp
+}
+
+//Execute executes the batch with a background context
+func (inp *BatchStatement) Execute(db StatementClient, items interface{}) (errs []error, err error) {
+	return inp.ExecuteWithContext(aws.BackgroundContext(), db, items)
+}
+
+//ExecuteWithContext executes the statements and decodes the item each of them
+//returns into items (a pointer to a slice, or nil), in the order they were
+//added. Statements that fail or don't return an item decode into the zero
+//value. The returned errs holds the error of each statement (nil if it
+//succeeded), failed conditions return the ConditionError if configured. err is
+//only set if the request as a whole failed.
+func (inp *BatchStatement) ExecuteWithContext(ctx aws.Context, db StatementClient, items interface{}) (errs []error, err error) {
+	table := ""
+	if len(inp.stmts) > 0 {
+		table, _ = statementTable(aws.StringValue(inp.stmts[0].Statement))
+	}
+
+	c := startCall(ctx, inp.Tracer, inp.Metrics, "BatchExecuteStatement", &table, nil)
+	defer func() { c.end(err) }()
+
+	if len(inp.stmts) == 0 || len(inp.stmts) > maxBatchStatements {
+		return nil, fmt.Errorf("batch must hold between 1 and %d statements, got: %d", maxBatchStatements, len(inp.stmts))
+	}
+
+	in := inp.BatchExecuteStatementInput
+	in.Statements = make([]*dynamodb.BatchStatementRequest, len(inp.stmts))
+	for i, st := range inp.stmts {
+		req := &dynamodb.BatchStatementRequest{Statement: st.Statement}
+		if req.Parameters, err = marshalParams(st.Params); err != nil {
+			return nil, fmt.Errorf("statement %d: %+v", i, err)
+		}
+
+		in.Statements[i] = req
+	}
+
+	var out *dynamodb.BatchExecuteStatementOutput
+	if out, err = db.BatchExecuteStatementWithContext(c.ctx, &in); err != nil {
+		c.fail(err)
+		return nil, fmt.Errorf("failed to perform request: %+v", err)
+	}
+
+	for _, cc := range out.ConsumedCapacity {
+		c.capacity(cc)
+	}
+
+	errs = make([]error, len(inp.stmts))
+	results := make([]map[string]*dynamodb.AttributeValue, len(inp.stmts))
+	for i, resp := range out.Responses {
+		if i >= len(results) {
+			break
+		}
+
+		results[i] = resp.Item
+		if results[i] == nil {
+			results[i] = map[string]*dynamodb.AttributeValue{}
+		}
+
+		if resp.Error != nil {
+			errs[i] = inp.mapError(resp.Error)
+		}
+	}
+
+	c.items(int64(len(out.Responses)))
+	if items != nil {
+		if err = unmarshalItems(results, items, inp.Encryption); err != nil {
+			return errs, fmt.Errorf("failed to unmarshal items: %+v", err)
+		}
+	}
+
+	return errs, nil
+}
+
+//mapError converts the error of a statement in the batch
+func (inp *BatchStatement) mapError(e *dynamodb.BatchStatementError) error {
+	code := aws.StringValue(e.Code)
+	if inp.ConditionError != nil && (code == dynamodb.BatchStatementErrorCodeEnumConditionalCheckFailed ||
+		code == dynamodb.BatchStatementErrorCodeEnumDuplicateItem) {
+		return inp.ConditionError
+	}
+
+	return awserr.New(code, aws.StringValue(e.Message), nil)
+}
+
+//marshalParams marshals the parameters of a statement
+func marshalParams(params []interface{}) ([]*dynamodb.AttributeValue, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	avs := make([]*dynamodb.AttributeValue, len(params))
+	for i, p := range params {
+		av, err := marshalValue(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal parameter %d: %+v", i+1, err)
+		}
+
+		avs[i] = av
+	}
+
+	return avs, nil
+}
+
+//statementTable returns the table (and index) a statement operates on, as
+//named after its FROM, INTO or UPDATE keyword. It is only used to instrument
+//the call and returns empty strings if the statement can't be understood.
+func statementTable(stmt string) (table, index string) {
+	words := strings.FieldsFunc(stmt, func(r rune) bool { return unicode.IsSpace(r) || r == '(' || r == ',' })
+	for i, w := range words {
+		switch strings.ToUpper(w) {
+		case "FROM", "INTO", "UPDATE":
+		default:
+			continue
+		}
+
+		if i+1 >= len(words) {
+			return "", ""
+		}
+
+		parts := strings.SplitN(words[i+1], ".", 2)
+		table = strings.Trim(parts[0], `"`)
+		if len(parts) > 1 {
+			index = strings.Trim(parts[1], `"`)
+		}
+
+		return table, index
+	}
+
+	return "", ""
+}
@@ -0,0 +1,125 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestStatementPages(t *testing.T) {
+	var inputs []*dynamodb.ExecuteStatementInput
+	db := &fakeDB{statement: func(in *dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error) {
+		inputs = append(inputs, in)
+		switch aws.StringValue(in.NextToken) {
+		case "":
+			return &dynamodb.ExecuteStatementOutput{Items: []map[string]*dynamodb.AttributeValue{item("Name", "a"), item("Name", "b")}, NextToken: aws.String("t1")}, nil
+		default:
+			return &dynamodb.ExecuteStatementOutput{Items: []map[string]*dynamodb.AttributeValue{item("Name", "c")}}, nil
+		}
+	}}
+
+	m := NewMemoryMetrics()
+	st := NewStatement(`SELECT * FROM "scores"."TitleIndex" WHERE GameTitle = ? AND TopScore > ?`, "Alien Adventure", 100)
+	st.SetMetrics(m)
+
+	var list []named
+	n, next, err := st.ExecutePage(aws.BackgroundContext(), db, &list)
+	ok(t, err)
+	equals(t, int64(2), n)
+	equals(t, "t1", aws.StringValue(next))
+	equals(t, []named{{"a"}, {"b"}}, list)
+	equals(t, []*dynamodb.AttributeValue{{S: aws.String("Alien Adventure")}, {N: aws.String("100")}}, inputs[0].Parameters)
+
+	st.SetMaxPages(AllPages)
+	list = nil
+	n, err = st.Execute(db, &list)
+	ok(t, err)
+	equals(t, int64(3), n)
+	equals(t, []named{{"a"}, {"b"}, {"c"}}, list)
+	equals(t, "t1", aws.StringValue(inputs[2].NextToken))
+	equals(t, (*string)(nil), st.NextToken)
+
+	snap := m.Snapshot()
+	equals(t, SeriesKey{"ExecuteStatement", "scores", "TitleIndex"}, snap[0].SeriesKey)
+	equals(t, int64(3), snap[0].Pages)
+}
+
+func TestStatementMaxItems(t *testing.T) {
+	var limits []int64
+	db := &fakeDB{statement: func(in *dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error) {
+		limits = append(limits, aws.Int64Value(in.Limit))
+		switch aws.StringValue(in.NextToken) {
+		case "":
+			return &dynamodb.ExecuteStatementOutput{Items: []map[string]*dynamodb.AttributeValue{item("Name", "a")}, NextToken: aws.String("t1")}, nil
+		case "t1":
+			return &dynamodb.ExecuteStatementOutput{Items: []map[string]*dynamodb.AttributeValue{item("Name", "b")}, NextToken: aws.String("t2")}, nil
+		default:
+			return &dynamodb.ExecuteStatementOutput{Items: []map[string]*dynamodb.AttributeValue{item("Name", "c")}}, nil
+		}
+	}}
+
+	st := NewStatement(`SELECT * FROM "scores" WHERE Name > ?`, "")
+	st.SetMaxItems(2)
+	var list []named
+	n, next, err := st.ExecutePage(aws.BackgroundContext(), db, &list)
+	ok(t, err)
+	equals(t, int64(2), n)
+	equals(t, "t2", aws.StringValue(next))
+	equals(t, []named{{"a"}, {"b"}}, list)
+	equals(t, []int64{2, 1}, limits)
+}
+
+func TestStatementConditionError(t *testing.T) {
+	db := &fakeDB{statement: func(in *dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error) {
+		return nil, awserr.New(dynamodb.ErrCodeDuplicateItemException, "Duplicate primary key exists in table", nil)
+	}}
+
+	st := NewStatement(`INSERT INTO scores VALUE {'GameTitle': ?, 'UserId': ?}`, "Alien Adventure", "User-1")
+	st.SetConditionError(errExists)
+	_, err := st.Execute(db, nil)
+	equals(t, errExists, err)
+}
+
+func TestBatchStatement(t *testing.T) {
+	db := &fakeDB{batchStmt: func(in *dynamodb.BatchExecuteStatementInput) (*dynamodb.BatchExecuteStatementOutput, error) {
+		equals(t, 2, len(in.Statements))
+		equals(t, "b", aws.StringValue(in.Statements[1].Parameters[0].S))
+		return &dynamodb.BatchExecuteStatementOutput{Responses: []*dynamodb.BatchStatementResponse{
+			{Item: item("Name", "a")},
+			{Error: &dynamodb.BatchStatementError{
+				Code:    aws.String(dynamodb.BatchStatementErrorCodeEnumConditionalCheckFailed),
+				Message: aws.String("The conditional request failed"),
+			}},
+		}}, nil
+	}}
+
+	b := NewBatchStatement().
+		Add(`SELECT * FROM "tbl" WHERE Name = ?`, "a").
+		Add(`SELECT * FROM "tbl" WHERE Name = ?`, "b")
+	b.SetConditionError(errExists)
+
+	var list []named
+	errs, err := b.Execute(db, &list)
+	ok(t, err)
+	equals(t, []error{nil, errExists}, errs)
+	equals(t, []named{{"a"}, {}}, list)
+
+	_, err = NewBatchStatement().Execute(db, nil)
+	equals(t, "batch must hold between 1 and 25 statements, got: 0", err.Error())
+}
+
+func TestStatementTable(t *testing.T) {
+	for stmt, exp := range map[string][2]string{
+		`SELECT * FROM "scores" WHERE a = ?`:      {"scores", ""},
+		`select a, b from scores.idx where a = ?`: {"scores", "idx"},
+		`INSERT INTO "scores" VALUE {'a': ?}`:     {"scores", ""},
+		`UPDATE scores SET a = ? WHERE b = ?`:     {"scores", ""},
+		`DELETE FROM "scores" WHERE a = ?`:        {"scores", ""},
+		`EXISTS(SELECT`:                           {"", ""},
+	} {
+		table, index := statementTable(stmt)
+		equals(t, exp, [2]string{table, index})
+	}
+}
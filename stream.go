@@ -0,0 +1,257 @@
+package dynamo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/advanderveer/go-dynamo/codec"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+//Event names of stream records
+const (
+	StreamInsert = dynamodbstreams.OperationTypeInsert
+	StreamModify = dynamodbstreams.OperationTypeModify
+	StreamRemove = dynamodbstreams.OperationTypeRemove
+)
+
+//StreamRecord describes a change to a single item as it is delivered by
+//DynamoDB Streams, the images are only present if the stream's view type
+//includes them. The images are decoded with the record's encryption, see
+//SetEncryption.
+type StreamRecord struct {
+	EncryptionInput
+	EventID                     string
+	EventName                   string
+	EventSourceARN              string
+	SequenceNumber              string
+	StreamViewType              string
+	ApproximateCreationDateTime time.Time
+	Keys                        map[string]*dynamodb.AttributeValue
+	OldImage                    map[string]*dynamodb.AttributeValue
+	NewImage                    map[string]*dynamodb.AttributeValue
+}
+
+//DecodeKeys decodes the key attributes into v like the builders decode items
+func (r *StreamRecord) DecodeKeys(v interface{}) error {
+	return unmarshalItem(r.Keys, v, r.Encryption)
+}
+
+//DecodeOldImage decodes the item as it was before the change into v like the
+//builders decode items
+func (r *StreamRecord) DecodeOldImage(v interface{}) error {
+	return unmarshalItem(r.OldImage, v, r.Encryption)
+}
+
+//DecodeNewImage decodes the item as it is after the change into v like the
+//builders decode items
+func (r *StreamRecord) DecodeNewImage(v interface{}) error {
+	return unmarshalItem(r.NewImage, v, r.Encryption)
+}
+
+//StreamRecordsFromSDK converts the records as they are returned by the
+//GetRecords operation of the dynamodbstreams client
+func StreamRecordsFromSDK(recs []*dynamodbstreams.Record) ([]StreamRecord, error) {
+	out := make([]StreamRecord, 0, len(recs))
+	for i, rec := range recs {
+		if rec.Dynamodb == nil {
+			return nil, fmt.Errorf("record %d has no stream record", i)
+		}
+
+		r := StreamRecord{
+			EventID:                     aws.StringValue(rec.EventID),
+			EventName:                   aws.StringValue(rec.EventName),
+			SequenceNumber:              aws.StringValue(rec.Dynamodb.SequenceNumber),
+			StreamViewType:              aws.StringValue(rec.Dynamodb.StreamViewType),
+			ApproximateCreationDateTime: aws.TimeValue(rec.Dynamodb.ApproximateCreationDateTime),
+			Keys:                        rec.Dynamodb.Keys,
+			OldImage:                    rec.Dynamodb.OldImage,
+			NewImage:                    rec.Dynamodb.NewImage,
+		}
+
+		out = append(out, r)
+	}
+
+	return out, nil
+}
+
+//ParseStreamEvent parses the records of a stream event as it is delivered to
+//a Lambda function, e.g. {"Records":[{"eventName":"INSERT","dynamodb":{...}}]}
+func ParseStreamEvent(data []byte) ([]StreamRecord, error) {
+	var ev struct {
+		Records []struct {
+			EventID        string `json:"eventID"`
+			EventName      string `json:"eventName"`
+			EventSourceARN string `json:"eventSourceARN"`
+			DynamoDB       struct {
+				ApproximateCreationDateTime float64
+				SequenceNumber              string
+				StreamViewType              string
+				Keys                        codec.Item
+				OldImage                    codec.Item
+				NewImage                    codec.Item
+			} `json:"dynamodb"`
+		}
+	}
+
+	if err := json.Unmarshal(data, &ev); err != nil {
+		return nil, fmt.Errorf("failed to decode stream event: %+v", err)
+	}
+
+	recs := make([]StreamRecord, 0, len(ev.Records))
+	for _, rec := range ev.Records {
+		r := StreamRecord{
+			EventID:        rec.EventID,
+			EventName:      rec.EventName,
+			EventSourceARN: rec.EventSourceARN,
+			SequenceNumber: rec.DynamoDB.SequenceNumber,
+			StreamViewType: rec.DynamoDB.StreamViewType,
+			Keys:           rec.DynamoDB.Keys,
+			OldImage:       rec.DynamoDB.OldImage,
+			NewImage:       rec.DynamoDB.NewImage,
+		}
+
+		if secs := rec.DynamoDB.ApproximateCreationDateTime; secs > 0 {
+			whole, frac := math.Modf(secs)
+			r.ApproximateCreationDateTime = time.Unix(int64(whole), int64(frac*1e9)).UTC()
+		}
+
+		recs = append(recs, r)
+	}
+
+	return recs, nil
+}
+
+//StreamChange is passed to stream handlers, Keys, Old and New hold pointers to
+//values of the handler's type (or nil if the record doesn't have them). For
+//handlers of unmapped items they hold the raw attribute value maps.
+type StreamChange struct {
+	Record *StreamRecord
+	Keys   interface{}
+	Old    interface{}
+	New    interface{}
+}
+
+//StreamHandler dispatches stream records to functions by their event name and
+//by the type their item is mapped onto. The items are decoded with the
+//handler's encryption, or with the record's if the handler has none.
+type StreamHandler struct {
+	EncryptionInput
+	types    *TypeMap
+	handlers []streamHandler
+}
+
+type streamHandler struct {
+	event string
+	typ   reflect.Type
+	fn    func(c StreamChange) error
+}
+
+//NewStreamHandler creates a handler that resolves the type of each record's
+//item with the type map, from its new image, old image or keys (in that
+//order). The type map may be nil if only unmapped items are handled.
+func NewStreamHandler(tm *TypeMap) *StreamHandler {
+	if tm == nil {
+		tm = NewTypeMap()
+	}
+
+	return &StreamHandler{types: tm}
+}
+
+//Handle calls fn for records with the event name (or any event if empty)
+//whose item is mapped onto the type of v, if v is nil fn is called for items
+//that are not mapped. Functions are called in the order they are registered.
+func (h *StreamHandler) Handle(event string, v interface{}, fn func(c StreamChange) error) *StreamHandler {
+	var typ reflect.Type
+	if v != nil {
+		typ = indirectType(reflect.TypeOf(v))
+	}
+
+	h.handlers = append(h.handlers, streamHandler{event, typ, fn})
+	return h
+}
+
+//HandleRecords dispatches the records in order, it stops at the first error.
+//Records without a matching handler are skipped.
+func (h *StreamHandler) HandleRecords(recs []StreamRecord) error {
+	for i := range recs {
+		rec := &recs[i]
+		typ := h.resolve(rec)
+		for _, sh := range h.handlers {
+			if sh.typ != typ || (sh.event != "" && sh.event != rec.EventName) {
+				continue
+			}
+
+			c, err := h.change(rec, typ)
+			if err != nil {
+				return fmt.Errorf("failed to decode record '%s': %+v", rec.EventID, err)
+			}
+
+			if err = sh.fn(c); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+//resolve returns the type the record's item is mapped onto, if any
+func (h *StreamHandler) resolve(rec *StreamRecord) reflect.Type {
+	for _, item := range []map[string]*dynamodb.AttributeValue{rec.NewImage, rec.OldImage, rec.Keys} {
+		if item == nil {
+			continue
+		}
+
+		if typ := h.types.Resolve(item); typ != nil {
+			return typ
+		}
+	}
+
+	return nil
+}
+
+//change decodes the keys and images of a record into new values of typ
+func (h *StreamHandler) change(rec *StreamRecord, typ reflect.Type) (c StreamChange, err error) {
+	c.Record = rec
+	e := h.Encryption
+	if e == nil {
+		e = rec.Encryption
+	}
+
+	decode := func(item map[string]*dynamodb.AttributeValue) (interface{}, error) {
+		if item == nil {
+			return nil, nil
+		}
+
+		if typ == nil {
+			return item, nil
+		}
+
+		ptr := reflect.New(typ).Interface()
+		if err := unmarshalItem(item, ptr, e); err != nil {
+			return nil, err
+		}
+
+		return ptr, nil
+	}
+
+	if c.Keys, err = decode(rec.Keys); err != nil {
+		return c, fmt.Errorf("failed to decode keys: %+v", err)
+	}
+
+	if c.Old, err = decode(rec.OldImage); err != nil {
+		return c, fmt.Errorf("failed to decode old image: %+v", err)
+	}
+
+	if c.New, err = decode(rec.NewImage); err != nil {
+		return c, fmt.Errorf("failed to decode new image: %+v", err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,86 @@
+package dynamo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+)
+
+const streamEvent = `{"Records":[
+	{"eventID":"1","eventName":"INSERT","eventSourceARN":"arn:aws:dynamodb:eu-west-1:123:table/tbl/stream/1","dynamodb":{
+		"ApproximateCreationDateTime":1700000000.5,"SequenceNumber":"100","StreamViewType":"NEW_AND_OLD_IMAGES",
+		"Keys":{"SK":{"S":"ORDER#1"}},"NewImage":{"SK":{"S":"ORDER#1"},"Total":{"N":"10"}}}},
+	{"eventID":"2","eventName":"MODIFY","dynamodb":{
+		"Keys":{"SK":{"S":"USER#1"}},"OldImage":{"SK":{"S":"USER#1"},"Name":{"S":"alice"}},"NewImage":{"SK":{"S":"USER#1"},"Name":{"S":"bob"}}}},
+	{"eventID":"3","eventName":"REMOVE","dynamodb":{"Keys":{"SK":{"S":"ORDER#1"}}}},
+	{"eventID":"4","eventName":"REMOVE","dynamodb":{"Keys":{"SK":{"S":"ADDRESS#1"}}}}
+]}`
+
+func TestParseStreamEvent(t *testing.T) {
+	recs, err := ParseStreamEvent([]byte(streamEvent))
+	ok(t, err)
+	equals(t, 4, len(recs))
+	equals(t, StreamInsert, recs[0].EventName)
+	equals(t, "100", recs[0].SequenceNumber)
+	equals(t, time.Unix(1700000000, 5e8).UTC(), recs[0].ApproximateCreationDateTime)
+	equals(t, map[string]*dynamodb.AttributeValue{"SK": {S: aws.String("ORDER#1")}}, recs[0].Keys)
+	equals(t, (map[string]*dynamodb.AttributeValue)(nil), recs[0].OldImage)
+
+	var o order
+	ok(t, recs[0].DecodeNewImage(&o))
+	equals(t, order{"ORDER#1", 10}, o)
+}
+
+func TestStreamRecordsFromSDK(t *testing.T) {
+	recs, err := StreamRecordsFromSDK([]*dynamodbstreams.Record{{
+		EventID:   aws.String("1"),
+		EventName: aws.String(StreamModify),
+		Dynamodb: &dynamodbstreams.StreamRecord{
+			Keys: map[string]*dynamodb.AttributeValue{"SK": {S: aws.String("USER#1")}},
+			NewImage: map[string]*dynamodb.AttributeValue{
+				"SK":   {S: aws.String("USER#1")},
+				"Tags": {L: []*dynamodb.AttributeValue{{M: map[string]*dynamodb.AttributeValue{"N": {N: aws.String("1")}}}}},
+			},
+		},
+	}})
+
+	ok(t, err)
+	equals(t, StreamModify, recs[0].EventName)
+	equals(t, &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{{M: map[string]*dynamodb.AttributeValue{"N": {N: aws.String("1")}}}}}, recs[0].NewImage["Tags"])
+}
+
+func TestStreamHandler(t *testing.T) {
+	recs, err := ParseStreamEvent([]byte(streamEvent))
+	ok(t, err)
+
+	var calls []string
+	tm := NewTypeMap().RegisterPrefix("SK", "USER#", user{}).RegisterPrefix("SK", "ORDER#", order{})
+	h := NewStreamHandler(tm).
+		Handle(StreamInsert, order{}, func(c StreamChange) error {
+			calls = append(calls, "insert order")
+			equals(t, &order{"ORDER#1", 10}, c.New)
+			equals(t, nil, c.Old)
+			return nil
+		}).
+		Handle(StreamModify, user{}, func(c StreamChange) error {
+			calls = append(calls, "modify user")
+			equals(t, "alice", c.Old.(*user).Name)
+			equals(t, "bob", c.New.(*user).Name)
+			return nil
+		}).
+		Handle("", order{}, func(c StreamChange) error {
+			calls = append(calls, c.Record.EventName+" order")
+			return nil
+		}).
+		Handle(StreamRemove, nil, func(c StreamChange) error {
+			calls = append(calls, "remove unknown")
+			equals(t, map[string]*dynamodb.AttributeValue{"SK": {S: aws.String("ADDRESS#1")}}, c.Keys)
+			return nil
+		})
+
+	ok(t, h.HandleRecords(recs))
+	equals(t, []string{"insert order", "INSERT order", "modify user", "REMOVE order", "remove unknown"}, calls)
+}
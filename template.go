@@ -0,0 +1,202 @@
+package dynamo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+//QueryTemplate is a query that is validated once and executed many times with
+//different parameter values. Value placeholders that are referenced by the
+//query's expressions but not defined are its parameters, e.g.:
+//
+//	q := NewQuery("scores", "GameTitle = :game")
+//	q.SetFilterExpression("#ts > :min")
+//	q.AddExpressionName("#ts", "TopScore")
+//	tpl, err := PrepareQuery(q)
+//	//for every request
+//	n, err := tpl.Execute(ctx, db, map[string]interface{}{"game": title, "min": 100}, &scores)
+type QueryTemplate struct {
+	query    Query
+	prepared *preparedExpressions
+}
+
+//PrepareQuery validates the query's expressions and converts its names and
+//values once, the query can be changed afterwards without affecting the template
+func PrepareQuery(q *Query) (*QueryTemplate, error) {
+	p, err := q.prepare(q.KeyConditionExpression, q.FilterExpression, q.ProjectionExpression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query: %+v", err)
+	}
+
+	return &QueryTemplate{query: *q.Clone(), prepared: p}, nil
+}
+
+//Params returns the parameters of the query, e.g. ":game"
+func (t *QueryTemplate) Params() []string { return append([]string(nil), t.prepared.params...) }
+
+//Bind returns a query with the parameter values that can be configured further
+//(e.g. to page from a key) and executed, see Execute for the parameters
+func (t *QueryTemplate) Bind(params interface{}) (*Query, error) {
+	vals, err := t.prepared.bind(params)
+	if err != nil {
+		return nil, err
+	}
+
+	q := t.query
+	q.ExpressionHolder = ExpressionHolder{ExpAttrValues: vals, prepared: t.prepared}
+	q.PagingInput = t.query.PagingInput.clone()
+	return &q, nil
+}
+
+//Execute binds the parameter values and executes the query. Parameters are
+//provided as a map (whose keys may omit the colon) or as a struct whose
+//attributes are marshalled like items, attributes that are not parameters are
+//ignored for structs.
+func (t *QueryTemplate) Execute(ctx aws.Context, db Client, params interface{}, items interface{}) (count int64, err error) {
+	q, err := t.Bind(params)
+	if err != nil {
+		return 0, err
+	}
+
+	return q.ExecuteWithContext(ctx, db, items)
+}
+
+//ScanTemplate is a scan that is validated once and executed many times with
+//different parameter values, see QueryTemplate
+type ScanTemplate struct {
+	scan     Scan
+	prepared *preparedExpressions
+}
+
+//PrepareScan validates the scan's expressions and converts its names and
+//values once, the scan can be changed afterwards without affecting the template
+func PrepareScan(s *Scan) (*ScanTemplate, error) {
+	p, err := s.prepare(s.FilterExpression, s.ProjectionExpression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare scan: %+v", err)
+	}
+
+	return &ScanTemplate{scan: *s.Clone(), prepared: p}, nil
+}
+
+//Params returns the parameters of the scan, e.g. ":min"
+func (t *ScanTemplate) Params() []string { return append([]string(nil), t.prepared.params...) }
+
+//Bind returns a scan with the parameter values that can be configured further
+//and executed, parameters are provided like for QueryTemplate.Execute
+func (t *ScanTemplate) Bind(params interface{}) (*Scan, error) {
+	vals, err := t.prepared.bind(params)
+	if err != nil {
+		return nil, err
+	}
+
+	s := t.scan
+	s.ExpressionHolder = ExpressionHolder{ExpAttrValues: vals, prepared: t.prepared}
+	s.PagingInput = t.scan.PagingInput.clone()
+	return &s, nil
+}
+
+//Execute binds the parameter values and executes the scan
+func (t *ScanTemplate) Execute(ctx aws.Context, db Client, params interface{}, items interface{}) (count int64, err error) {
+	s, err := t.Bind(params)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.ExecuteWithContext(ctx, db, items)
+}
+
+//UpdateTemplate is an update that is validated once and executed many times
+//with different primary keys and parameter values, see QueryTemplate
+type UpdateTemplate struct {
+	update   Update
+	prepared *preparedExpressions
+}
+
+//PrepareUpdate validates the update's expressions and converts its names and
+//values once, the primary key of the update is not used
+func PrepareUpdate(upd *Update) (*UpdateTemplate, error) {
+	p, err := upd.prepare(upd.UpdateExpression, upd.ConditionExpression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare update: %+v", err)
+	}
+
+	t := &UpdateTemplate{update: *upd.Clone(), prepared: p}
+	t.update.PrimaryKey = nil
+	return t, nil
+}
+
+//Params returns the parameters of the update, e.g. ":score"
+func (t *UpdateTemplate) Params() []string { return append([]string(nil), t.prepared.params...) }
+
+//Bind returns an update of the item with primary key pk and the parameter
+//values, parameters are provided like for QueryTemplate.Execute
+func (t *UpdateTemplate) Bind(pk interface{}, params interface{}) (*Update, error) {
+	vals, err := t.prepared.bind(params)
+	if err != nil {
+		return nil, err
+	}
+
+	upd := t.update
+	upd.ExpressionHolder = ExpressionHolder{ExpAttrValues: vals, prepared: t.prepared}
+	upd.PrimaryKey = pk
+	return &upd, nil
+}
+
+//Execute binds the primary key and parameter values and executes the update
+func (t *UpdateTemplate) Execute(ctx aws.Context, db Client, pk interface{}, params interface{}) error {
+	upd, err := t.Bind(pk, params)
+	if err != nil {
+		return err
+	}
+
+	return upd.ExecuteWithContext(ctx, db)
+}
+
+//bind converts the parameter values of a map or struct and checks them
+//against the parameters of the template
+func (p *preparedExpressions) bind(params interface{}) (map[string]interface{}, error) {
+	vals := map[string]interface{}{}
+	switch pv := params.(type) {
+	case nil:
+	case map[string]interface{}:
+		for name, val := range pv {
+			vals[":"+strings.TrimLeft(name, ":")] = val
+		}
+	default:
+		item, err := marshalItem(params, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal parameters: %+v", err)
+		}
+
+		isStruct := reflect.Indirect(reflect.ValueOf(params)).Kind() == reflect.Struct
+		for name, av := range item {
+			ph := ":" + strings.TrimLeft(name, ":")
+			if isStruct && !p.isParam(ph) {
+				continue
+			}
+
+			vals[ph] = av
+		}
+	}
+
+	if err := p.check(vals); err != nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
+
+//isParam reports whether the placeholder is a parameter
+func (p *preparedExpressions) isParam(ph string) bool {
+	for _, param := range p.params {
+		if param == ph {
+			return true
+		}
+	}
+
+	return false
+}
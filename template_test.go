@@ -0,0 +1,91 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestQueryTemplate(t *testing.T) {
+	var inputs []*dynamodb.QueryInput
+	db := &fakeDB{query: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		inputs = append(inputs, in)
+		return &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{item("Name", "a")}}, nil
+	}}
+
+	q := NewQuery("scores", "GameTitle = :game")
+	q.SetFilterExpression("#ts > :min AND Mode = :mode")
+	q.AddExpressionName("#ts", "TopScore")
+	q.AddExpressionValue(":mode", "ranked")
+
+	tpl, err := PrepareQuery(q)
+	ok(t, err)
+	equals(t, []string{":game", ":min"}, tpl.Params())
+
+	q.AddExpressionValue(":game", "changed") //doesn't affect the template
+	ctx := aws.BackgroundContext()
+	_, err = tpl.Execute(ctx, db, map[string]interface{}{"game": "Alien Adventure", ":min": 100}, &[]named{})
+	ok(t, err)
+
+	_, err = tpl.Execute(ctx, db, struct {
+		Game  string `dynamodbav:"game"`
+		Min   int    `dynamodbav:"min"`
+		Other string
+	}{"Galaxy Invaders", 10, "ignored"}, &[]named{})
+	ok(t, err)
+
+	equals(t, 2, len(inputs))
+	equals(t, map[string]*string{"#ts": aws.String("TopScore")}, inputs[0].ExpressionAttributeNames)
+	equals(t, map[string]*dynamodb.AttributeValue{
+		":game": {S: aws.String("Alien Adventure")},
+		":min":  {N: aws.String("100")},
+		":mode": {S: aws.String("ranked")},
+	}, inputs[0].ExpressionAttributeValues)
+	equals(t, "Galaxy Invaders", aws.StringValue(inputs[1].ExpressionAttributeValues[":game"].S))
+	equals(t, "10", aws.StringValue(inputs[1].ExpressionAttributeValues[":min"].N))
+
+	_, err = tpl.Execute(ctx, db, map[string]interface{}{"game": "x", "max": 1}, &[]named{})
+	equals(t, "invalid template parameters, missing parameters: :min; unknown parameters: :max", err.Error())
+
+	//a bound query can be reconfigured, it is then validated as a whole
+	bq, err := tpl.Bind(map[string]interface{}{"game": "x", "min": 1})
+	ok(t, err)
+	bq.SetProjectionExpression("#n")
+	bq.AddExpressionName("#n", "Name")
+	_, err = bq.ExecuteWithContext(ctx, db, &[]named{})
+	ok(t, err)
+	equals(t, map[string]*string{"#ts": aws.String("TopScore"), "#n": aws.String("Name")}, inputs[2].ExpressionAttributeNames)
+	equals(t, 3, len(inputs[2].ExpressionAttributeValues))
+
+	q = NewQuery("scores", "#g = :game")
+	_, err = PrepareQuery(q)
+	equals(t, "failed to prepare query: invalid expression placeholders, undefined names: #g", err.Error())
+}
+
+func TestUpdateTemplate(t *testing.T) {
+	var inputs []*dynamodb.UpdateItemInput
+	db := &fakeDB{updateItem: func(in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		inputs = append(inputs, in)
+		return &dynamodb.UpdateItemOutput{}, nil
+	}}
+
+	upd := NewUpdate("scores", nil)
+	upd.SetUpdateExpression("SET TopScore = :score ADD Plays :one")
+	upd.SetConditionExpression("TopScore < :score")
+	upd.AddExpressionValue(":one", 1)
+
+	tpl, err := PrepareUpdate(upd)
+	ok(t, err)
+	equals(t, []string{":score"}, tpl.Params())
+
+	for i, id := range []string{"a", "b"} {
+		ok(t, tpl.Execute(aws.BackgroundContext(), db, named{id}, map[string]interface{}{"score": 10 + i}))
+	}
+
+	equals(t, item("Name", "b"), inputs[1].Key)
+	equals(t, map[string]*dynamodb.AttributeValue{
+		":score": {N: aws.String("11")},
+		":one":   {N: aws.String("1")},
+	}, inputs[1].ExpressionAttributeValues)
+}
@@ -0,0 +1,182 @@
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//Span attribute keys that are set by the builders
+const (
+	AttrTable            = "dynamo.table"
+	AttrIndex            = "dynamo.index"
+	AttrOperation        = "dynamo.operation"
+	AttrPage             = "dynamo.page"
+	AttrItems            = "dynamo.items"
+	AttrScannedItems     = "dynamo.scanned_items"
+	AttrPages            = "dynamo.pages"
+	AttrConsumedCapacity = "dynamo.consumed_capacity"
+	AttrErrorClass       = "dynamo.error_class"
+	AttrNotFound         = "dynamo.not_found"
+)
+
+//Error classes as reported by ErrorClass
+const (
+	ErrClassConditionFailed  = "condition_failed"
+	ErrClassThrottled        = "throttled"
+	ErrClassValidation       = "validation"
+	ErrClassResourceNotFound = "resource_not_found"
+	ErrClassCanceled         = "canceled"
+	ErrClassServer           = "server"
+	ErrClassClient           = "client"
+	ErrClassOther            = "other"
+)
+
+//ErrorClass returns a coarse classification of an error that was returned by
+//the AWS SDK, it returns an empty string for a nil error
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrClassCanceled
+	}
+
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return ErrClassClient
+	}
+
+	switch aerr.Code() {
+	case dynamodb.ErrCodeConditionalCheckFailedException:
+		return ErrClassConditionFailed
+	case dynamodb.ErrCodeProvisionedThroughputExceededException,
+		dynamodb.ErrCodeRequestLimitExceeded,
+		"ThrottlingException":
+		return ErrClassThrottled
+	case "ValidationException", "SerializationException":
+		return ErrClassValidation
+	case dynamodb.ErrCodeResourceNotFoundException:
+		return ErrClassResourceNotFound
+	case request.CanceledErrorCode:
+		return ErrClassCanceled
+	case dynamodb.ErrCodeInternalServerError, "ServiceUnavailable":
+		return ErrClassServer
+	default:
+		return ErrClassOther
+	}
+}
+
+//Attribute annotates a span with a key and value
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+//Span is a single unit of traced work, it is modelled after the OpenTelemetry
+//span such that tracers can be adapted with a thin wrapper
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+//Tracer starts spans, the returned context carries the span such that
+//spans that are started from it become its children
+type Tracer interface {
+	Start(ctx aws.Context, name string) (aws.Context, Span)
+}
+
+//NoopTracer is a tracer that doesn't record anything, it is used when no
+//tracer is configured
+type NoopTracer struct{}
+
+//Start returns the context unchanged together with a span that does nothing
+func (NoopTracer) Start(ctx aws.Context, name string) (aws.Context, Span) { return ctx, noopSpan{} }
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(attrs ...Attribute) {}
+func (noopSpan) RecordError(err error)            {}
+func (noopSpan) End()                             {}
+
+//RecordedSpan is a span as it was recorded by the RecordingTracer
+type RecordedSpan struct {
+	Name       string
+	Parent     *RecordedSpan
+	Attributes map[string]interface{}
+	Err        error
+	Ended      bool
+
+	tracer *RecordingTracer
+}
+
+//SetAttributes stores the attributes on the span
+func (s *RecordedSpan) SetAttributes(attrs ...Attribute) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	for _, attr := range attrs {
+		s.Attributes[attr.Key] = attr.Value
+	}
+}
+
+//RecordError stores the error on the span
+func (s *RecordedSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Err = err
+}
+
+//End marks the span as ended
+func (s *RecordedSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.Ended = true
+}
+
+type recordedSpanKey struct{}
+
+//RecordingTracer keeps all spans in memory, it is meant to be used in tests
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+//Start records a new span as a child of the span in the context (if any)
+func (t *RecordingTracer) Start(ctx aws.Context, name string) (aws.Context, Span) {
+	parent, _ := ctx.Value(recordedSpanKey{}).(*RecordedSpan)
+	s := &RecordedSpan{Name: name, Parent: parent, Attributes: map[string]interface{}{}, tracer: t}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, recordedSpanKey{}, s), s
+}
+
+//Spans returns all spans that were started, in the order they were started
+func (t *RecordingTracer) Spans() []*RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*RecordedSpan{}, t.spans...)
+}
+
+//Reset forgets all recorded spans
+func (t *RecordingTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+//TracingInput is used when an execution can be traced
+type TracingInput struct {
+	Tracer Tracer
+}
+
+//SetTracer configures the tracer that receives a span for each execution
+func (ti *TracingInput) SetTracer(t Tracer) { ti.Tracer = t }
@@ -0,0 +1,85 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestQueryTracing(t *testing.T) {
+	tr := &RecordingTracer{}
+	db := &fakeDB{query: pages(2, item("Name", "a"), item("Name", "b"), item("Name", "c"))}
+
+	q := NewQuery("tbl", "Name = :name")
+	q.AddExpressionValue(":name", "a")
+	q.SetIndexName("NameIndex")
+	q.SetTracer(tr)
+	q.SetMaxPages(5)
+
+	list := []struct{ Name string }{}
+	n, err := q.Execute(db, &list)
+	ok(t, err)
+	equals(t, int64(3), n)
+	equals(t, 3, len(list))
+	equals(t, "c", list[2].Name)
+
+	spans := tr.Spans()
+	equals(t, 3, len(spans))
+	equals(t, "dynamo.Query", spans[0].Name)
+	equals(t, "tbl", spans[0].Attributes[AttrTable])
+	equals(t, "NameIndex", spans[0].Attributes[AttrIndex])
+	equals(t, int64(3), spans[0].Attributes[AttrItems])
+	equals(t, 2, spans[0].Attributes[AttrPages])
+	equals(t, true, spans[0].Ended)
+
+	equals(t, "dynamo.Query.page", spans[1].Name)
+	equals(t, spans[0], spans[1].Parent)
+	equals(t, int64(2), spans[1].Attributes[AttrItems])
+	equals(t, int64(1), spans[2].Attributes[AttrItems])
+}
+
+func TestPutTracingConditionError(t *testing.T) {
+	tr := &RecordingTracer{}
+	db := &fakeDB{putItem: func(in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+		return nil, awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "failed", nil)
+	}}
+
+	put := NewPut("tbl", struct{ Name string }{"a"})
+	put.SetTracer(tr)
+	put.SetConditionExpression("attribute_not_exists(Name)")
+	put.SetConditionError(errExists)
+	equals(t, errExists, put.ExecuteWithContext(aws.BackgroundContext(), db))
+
+	spans := tr.Spans()
+	equals(t, 1, len(spans))
+	equals(t, "dynamo.PutItem", spans[0].Name)
+	equals(t, ErrClassConditionFailed, spans[0].Attributes[AttrErrorClass])
+	equals(t, errExists, spans[0].Err)
+}
+
+func TestGetTracingNotFound(t *testing.T) {
+	tr := &RecordingTracer{}
+	db := &fakeDB{getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		return &dynamodb.GetItemOutput{}, nil
+	}}
+
+	g := NewGet("tbl", struct{ Name string }{"a"})
+	g.SetTracer(tr)
+	g.SetItemNilError(errExists)
+	equals(t, errExists, g.Execute(db, &struct{ Name string }{}))
+
+	spans := tr.Spans()
+	equals(t, 1, len(spans))
+	equals(t, true, spans[0].Attributes[AttrNotFound])
+	equals(t, nil, spans[0].Attributes[AttrErrorClass])
+	equals(t, nil, spans[0].Err)
+}
+
+func TestErrorClass(t *testing.T) {
+	equals(t, "", ErrorClass(nil))
+	equals(t, ErrClassThrottled, ErrorClass(awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "slow down", nil)))
+	equals(t, ErrClassValidation, ErrorClass(awserr.New("ValidationException", "invalid", nil)))
+	equals(t, ErrClassClient, ErrorClass(errExists))
+}
@@ -0,0 +1,44 @@
+package dynamo
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+//GetAs executes the get and returns the item as a value of type T, the zero
+//value is returned if the item doesn't exist and no ItemNilError is configured
+func GetAs[T any](ctx aws.Context, db Client, get *Get) (item T, err error) {
+	err = get.ExecuteWithContext(ctx, db, &item)
+	return item, err
+}
+
+//QueryAll executes the query and returns the items of all pages as values of
+//type T. Unlike ExecuteWithContext it fetches every page unless the query's
+//MaxPages or MaxItems is set, in which case those limits apply.
+func QueryAll[T any](ctx aws.Context, db Client, q *Query) (items []T, err error) {
+	items = []T{}
+	if _, err = q.execute(ctx, db, &items, allPagesByDefault(q.PagingInput), false); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+//ScanAll executes the scan and returns the items of all pages as values of
+//type T, see QueryAll for the limits that apply
+func ScanAll[T any](ctx aws.Context, db Client, s *Scan) (items []T, err error) {
+	items = []T{}
+	if _, err = s.execute(ctx, db, &items, allPagesByDefault(s.PagingInput), false, s.Segment); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+//allPagesByDefault configures paging to fetch all pages if no limit is set
+func allPagesByDefault(pi PagingInput) PagingInput {
+	if pi.MaxPages == 0 && pi.MaxItems == 0 {
+		pi.MaxPages = AllPages
+	}
+
+	return pi
+}
@@ -0,0 +1,53 @@
+package dynamo
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type named struct {
+	Name string
+}
+
+func TestTypedGetQueryScan(t *testing.T) {
+	ctx := aws.BackgroundContext()
+	db := &fakeDB{
+		getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			if aws.StringValue(in.Key["Name"].S) != "a" {
+				return &dynamodb.GetItemOutput{}, nil
+			}
+
+			return &dynamodb.GetItemOutput{Item: item("Name", "a")}, nil
+		},
+		query: pages(1, item("Name", "a"), item("Name", "b")),
+		scan: func(in *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+			return &dynamodb.ScanOutput{Items: []map[string]*dynamodb.AttributeValue{item("Name", "c")}, Count: aws.Int64(1)}, nil
+		},
+	}
+
+	it, err := GetAs[named](ctx, db, NewGet("tbl", named{"a"}))
+	ok(t, err)
+	equals(t, named{"a"}, it)
+
+	ptr, err := GetAs[*named](ctx, db, NewGet("tbl", named{"x"}))
+	ok(t, err)
+	equals(t, (*named)(nil), ptr)
+
+	q := NewQuery("tbl", "Name = :name")
+	q.AddExpressionValue(":name", "a")
+	list, err := QueryAll[*named](ctx, db, q)
+	ok(t, err)
+	equals(t, []*named{{"a"}, {"b"}}, list)
+	equals(t, 0, q.MaxPages)
+
+	q.SetMaxPages(1)
+	list, err = QueryAll[*named](ctx, db, q)
+	ok(t, err)
+	equals(t, []*named{{"a"}}, list)
+
+	list2, err := ScanAll[named](ctx, db, NewScan("tbl"))
+	ok(t, err)
+	equals(t, []named{{"c"}}, list2)
+}
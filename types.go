@@ -0,0 +1,535 @@
+package dynamo
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+//TypeMarshaller encodes values of type T as attribute values and decodes them,
+//see RegisterType
+type TypeMarshaller[T any] interface {
+	MarshalAttribute(v T) (*dynamodb.AttributeValue, error)
+	UnmarshalAttribute(av *dynamodb.AttributeValue) (T, error)
+}
+
+//TypeFuncs implements a TypeMarshaller with a pair of functions
+type TypeFuncs[T any] struct {
+	Marshal   func(v T) (*dynamodb.AttributeValue, error)
+	Unmarshal func(av *dynamodb.AttributeValue) (T, error)
+}
+
+//MarshalAttribute calls Marshal
+func (f TypeFuncs[T]) MarshalAttribute(v T) (*dynamodb.AttributeValue, error) { return f.Marshal(v) }
+
+//UnmarshalAttribute calls Unmarshal
+func (f TypeFuncs[T]) UnmarshalAttribute(av *dynamodb.AttributeValue) (T, error) {
+	return f.Unmarshal(av)
+}
+
+//typeCoder holds a registered marshaller for values of any type
+type typeCoder struct {
+	marshal   func(v reflect.Value) (*dynamodb.AttributeValue, error)
+	unmarshal func(av *dynamodb.AttributeValue, v reflect.Value) error
+}
+
+var types = struct {
+	sync.RWMutex
+	m map[reflect.Type]typeCoder
+
+	//has caches whether values of a type contain registered types
+	has map[reflect.Type]bool
+}{m: map[reflect.Type]typeCoder{}, has: map[reflect.Type]bool{}}
+
+//RegisterType makes the builders use the marshaller for values of type T in
+//items, primary keys, expression values and statement parameters, and when
+//decoding results. It applies to struct fields, map and slice elements and
+//pointers of type T at any depth, e.g.:
+//
+//	dynamo.RegisterType(dynamo.EpochSeconds)              //sortable timestamps
+//	dynamo.RegisterType(dynamo.Text[uuid.UUID]())         //UUIDs as strings
+//	dynamo.RegisterType(dynamo.Numeric[decimal.Decimal]()) //decimals as numbers
+//
+//Registering a type again replaces its marshaller. Types should be registered
+//before items are marshalled, fields with coding options (e.g. compress) and
+//composite key templates are not affected.
+func RegisterType[T any](m TypeMarshaller[T]) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	types.Lock()
+	defer types.Unlock()
+	types.m[t] = typeCoder{
+		marshal: func(v reflect.Value) (*dynamodb.AttributeValue, error) {
+			return m.MarshalAttribute(v.Interface().(T))
+		},
+		unmarshal: func(av *dynamodb.AttributeValue, v reflect.Value) error {
+			x, err := m.UnmarshalAttribute(av)
+			if err != nil {
+				return err
+			}
+
+			v.Set(reflect.ValueOf(&x).Elem())
+			return nil
+		},
+	}
+
+	types.has = map[reflect.Type]bool{}
+}
+
+//EpochSeconds encodes times as a number of seconds since the Unix epoch, e.g.
+//for sort keys or TTL attributes. Times are decoded in UTC.
+var EpochSeconds TypeMarshaller[time.Time] = TypeFuncs[time.Time]{
+	Marshal: func(t time.Time) (*dynamodb.AttributeValue, error) {
+		return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(t.Unix(), 10))}, nil
+	},
+	Unmarshal: func(av *dynamodb.AttributeValue) (time.Time, error) {
+		n, err := numberAttribute(av)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(n, 0).UTC(), nil
+	},
+}
+
+//EpochMillis encodes times as a number of milliseconds since the Unix epoch.
+//Times are decoded in UTC.
+var EpochMillis TypeMarshaller[time.Time] = TypeFuncs[time.Time]{
+	Marshal: func(t time.Time) (*dynamodb.AttributeValue, error) {
+		return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(t.UnixMilli(), 10))}, nil
+	},
+	Unmarshal: func(av *dynamodb.AttributeValue) (time.Time, error) {
+		n, err := numberAttribute(av)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.UnixMilli(n).UTC(), nil
+	},
+}
+
+//sortableRFC3339 formats times with a fixed number of fractional digits, such
+//that times in UTC sort lexically
+const sortableRFC3339 = "2006-01-02T15:04:05.000000000Z07:00"
+
+//RFC3339 encodes times as RFC3339 strings in UTC with nanoseconds, unlike the
+//default encoding these sort lexically
+var RFC3339 TypeMarshaller[time.Time] = TypeFuncs[time.Time]{
+	Marshal: func(t time.Time) (*dynamodb.AttributeValue, error) {
+		return &dynamodb.AttributeValue{S: aws.String(t.UTC().Format(sortableRFC3339))}, nil
+	},
+	Unmarshal: func(av *dynamodb.AttributeValue) (time.Time, error) {
+		if av.S == nil {
+			return time.Time{}, fmt.Errorf("expected a string attribute, got: %s", av)
+		}
+
+		return time.Parse(time.RFC3339Nano, *av.S)
+	},
+}
+
+//DurationString encodes durations as strings like "1h30m" instead of a number
+//of nanoseconds
+var DurationString TypeMarshaller[time.Duration] = TypeFuncs[time.Duration]{
+	Marshal: func(d time.Duration) (*dynamodb.AttributeValue, error) {
+		return &dynamodb.AttributeValue{S: aws.String(d.String())}, nil
+	},
+	Unmarshal: func(av *dynamodb.AttributeValue) (time.Duration, error) {
+		if av.S == nil {
+			return 0, fmt.Errorf("expected a string attribute, got: %s", av)
+		}
+
+		return time.ParseDuration(*av.S)
+	},
+}
+
+//Text encodes values as strings with their text encoding, e.g. for UUIDs
+func Text[T encoding.TextMarshaler, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}]() TypeMarshaller[T] {
+	return TypeFuncs[T]{
+		Marshal: func(v T) (*dynamodb.AttributeValue, error) {
+			b, err := v.MarshalText()
+			if err != nil {
+				return nil, err
+			}
+
+			return &dynamodb.AttributeValue{S: aws.String(string(b))}, nil
+		},
+		Unmarshal: func(av *dynamodb.AttributeValue) (v T, err error) {
+			if av.S == nil {
+				return v, fmt.Errorf("expected a string attribute, got: %s", av)
+			}
+
+			err = PT(&v).UnmarshalText([]byte(*av.S))
+			return v, err
+		},
+	}
+}
+
+//Numeric encodes values as numbers with their text encoding, e.g. for decimal
+//types whose text encoding is a decimal number
+func Numeric[T encoding.TextMarshaler, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}]() TypeMarshaller[T] {
+	return TypeFuncs[T]{
+		Marshal: func(v T) (*dynamodb.AttributeValue, error) {
+			b, err := v.MarshalText()
+			if err != nil {
+				return nil, err
+			}
+
+			return &dynamodb.AttributeValue{N: aws.String(string(b))}, nil
+		},
+		Unmarshal: func(av *dynamodb.AttributeValue) (v T, err error) {
+			if av.N == nil {
+				return v, fmt.Errorf("expected a number attribute, got: %s", av)
+			}
+
+			err = PT(&v).UnmarshalText([]byte(*av.N))
+			return v, err
+		},
+	}
+}
+
+//Enum encodes the values of an enum type as the strings they're mapped to,
+//values that are not mapped fail to encode and decode
+func Enum[T comparable](names map[T]string) TypeMarshaller[T] {
+	values := make(map[string]T, len(names))
+	for v, name := range names {
+		values[name] = v
+	}
+
+	return TypeFuncs[T]{
+		Marshal: func(v T) (*dynamodb.AttributeValue, error) {
+			name, ok := names[v]
+			if !ok {
+				return nil, fmt.Errorf("no name for enum value %v", v)
+			}
+
+			return &dynamodb.AttributeValue{S: aws.String(name)}, nil
+		},
+		Unmarshal: func(av *dynamodb.AttributeValue) (v T, err error) {
+			if av.S == nil {
+				return v, fmt.Errorf("expected a string attribute, got: %s", av)
+			}
+
+			v, ok := values[*av.S]
+			if !ok {
+				return v, fmt.Errorf("unknown enum value '%s'", *av.S)
+			}
+
+			return v, nil
+		},
+	}
+}
+
+//numberAttribute parses an integer number attribute
+func numberAttribute(av *dynamodb.AttributeValue) (int64, error) {
+	if av.N == nil {
+		return 0, fmt.Errorf("expected a number attribute, got: %s", av)
+	}
+
+	return strconv.ParseInt(*av.N, 10, 64)
+}
+
+//typeCoderOf returns the registered marshaller of type t, if any
+func typeCoderOf(t reflect.Type) (typeCoder, bool) {
+	types.RLock()
+	defer types.RUnlock()
+	c, ok := types.m[t]
+	return c, ok
+}
+
+//hasTypes reports whether values of type t contain values of registered types
+func hasTypes(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+
+	types.RLock()
+	has, ok := types.has[t]
+	empty := len(types.m) == 0
+	types.RUnlock()
+	if ok || empty {
+		return has
+	}
+
+	has = containsTypes(t, map[reflect.Type]bool{})
+	types.Lock()
+	defer types.Unlock()
+	types.has[t] = has
+	return has
+}
+
+//containsTypes walks type t for registered types, seen guards against
+//recursive types. Interfaces may hold values of registered types, which can
+//only be determined by walking the value.
+func containsTypes(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+
+	seen[t] = true
+	if _, ok := typeCoderOf(t); ok {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return containsTypes(t.Elem(), seen)
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && containsTypes(t.Elem(), seen)
+	case reflect.Struct:
+		for _, f := range structFields(t) {
+			if len(f.Coding) == 0 && containsTypes(f.Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//encodeTypes replaces the parts of attribute value av that encode values of
+//registered types in v with the result of their marshaller. Attributes that
+//are absent or null (e.g. for nil pointers) are left alone.
+func encodeTypes(v reflect.Value, av *dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	if !v.IsValid() || av == nil || aws.BoolValue(av.NULL) {
+		return av, nil
+	}
+
+	if c, ok := typeCoderOf(v.Type()); ok {
+		return c.marshal(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return av, nil
+		}
+
+		return encodeTypes(v.Elem(), av)
+	case reflect.Struct:
+		if av.M == nil {
+			return av, nil
+		}
+
+		for _, f := range structFields(v.Type()) {
+			sub, ok := av.M[f.Name]
+			if !ok || len(f.Coding) > 0 {
+				continue
+			}
+
+			fv, err := v.FieldByIndexErr(f.Index)
+			if err != nil {
+				continue
+			}
+
+			if av.M[f.Name], err = encodeTypes(fv, sub); err != nil {
+				return nil, fmt.Errorf("field '%s': %+v", f.Name, err)
+			}
+		}
+	case reflect.Map:
+		if av.M == nil || v.Type().Key().Kind() != reflect.String {
+			return av, nil
+		}
+
+		for _, k := range v.MapKeys() {
+			sub, ok := av.M[k.String()]
+			if !ok {
+				continue
+			}
+
+			var err error
+			if av.M[k.String()], err = encodeTypes(v.MapIndex(k), sub); err != nil {
+				return nil, fmt.Errorf("key '%s': %+v", k.String(), err)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if len(av.L) != v.Len() {
+			return av, nil
+		}
+
+		for i := range av.L {
+			var err error
+			if av.L[i], err = encodeTypes(v.Index(i), av.L[i]); err != nil {
+				return nil, fmt.Errorf("element %d: %+v", i, err)
+			}
+		}
+	}
+
+	return av, nil
+}
+
+//encodeItemTypes encodes the values of registered types in the attributes of
+//item m that was marshalled from v
+func encodeItemTypes(v interface{}, m map[string]*dynamodb.AttributeValue) error {
+	if !hasTypes(reflect.TypeOf(v)) {
+		return nil
+	}
+
+	_, err := encodeTypes(reflect.ValueOf(v), &dynamodb.AttributeValue{M: m})
+	return err
+}
+
+//stripTypes returns a copy of attribute value av in which the parts that
+//decode into values of registered types of type t are null, such that the
+//rest can be decoded as usual
+func stripTypes(t reflect.Type, av *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if av == nil || aws.BoolValue(av.NULL) {
+		return av
+	}
+
+	if _, ok := typeCoderOf(t); ok {
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return stripTypes(t.Elem(), av)
+	case reflect.Struct:
+		if av.M == nil {
+			return av
+		}
+
+		m := make(map[string]*dynamodb.AttributeValue, len(av.M))
+		for name, sub := range av.M {
+			m[name] = sub
+		}
+
+		for _, f := range structFields(t) {
+			if sub, ok := m[f.Name]; ok && len(f.Coding) == 0 {
+				m[f.Name] = stripTypes(f.Type, sub)
+			}
+		}
+
+		return &dynamodb.AttributeValue{M: m}
+	case reflect.Map:
+		if av.M == nil || t.Key().Kind() != reflect.String {
+			return av
+		}
+
+		m := make(map[string]*dynamodb.AttributeValue, len(av.M))
+		for name, sub := range av.M {
+			m[name] = stripTypes(t.Elem(), sub)
+		}
+
+		return &dynamodb.AttributeValue{M: m}
+	case reflect.Slice, reflect.Array:
+		if av.L == nil {
+			return av
+		}
+
+		l := make([]*dynamodb.AttributeValue, len(av.L))
+		for i, sub := range av.L {
+			l[i] = stripTypes(t.Elem(), sub)
+		}
+
+		return &dynamodb.AttributeValue{L: l}
+	}
+
+	return av
+}
+
+//decodeTypes decodes the parts of attribute value av that were stripped by
+//stripTypes into the (settable) value v that the rest was decoded into
+func decodeTypes(av *dynamodb.AttributeValue, v reflect.Value) error {
+	if av == nil || aws.BoolValue(av.NULL) {
+		return nil
+	}
+
+	if c, ok := typeCoderOf(v.Type()); ok {
+		return c.unmarshal(av, v)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return decodeTypes(av, v.Elem())
+	case reflect.Struct:
+		if av.M == nil {
+			return nil
+		}
+
+		for _, f := range structFields(v.Type()) {
+			sub, ok := av.M[f.Name]
+			if !ok || len(f.Coding) > 0 || !hasTypes(f.Type) {
+				continue
+			}
+
+			if err := decodeTypes(sub, fieldByIndex(v, f.Index)); err != nil {
+				return fmt.Errorf("field '%s': %+v", f.Name, err)
+			}
+		}
+	case reflect.Map:
+		if av.M == nil || v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+
+		for name, sub := range av.M {
+			k := reflect.ValueOf(name).Convert(v.Type().Key())
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if cur := v.MapIndex(k); cur.IsValid() {
+				elem.Set(cur)
+			}
+
+			if err := decodeTypes(sub, elem); err != nil {
+				return fmt.Errorf("key '%s': %+v", name, err)
+			}
+
+			v.SetMapIndex(k, elem)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < len(av.L) && i < v.Len(); i++ {
+			if err := decodeTypes(av.L[i], v.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %+v", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//fieldByIndex returns the field of struct value v at index, embedded struct
+//pointers are allocated as needed
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	return v
+}
+
+//stripItemTypes strips the attributes of item m that decode into values of
+//registered types of t, see stripTypes. The item is returned as is if t is nil.
+func stripItemTypes(t reflect.Type, m map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	if t == nil {
+		return m
+	}
+
+	return stripTypes(t, &dynamodb.AttributeValue{M: m}).M
+}
@@ -0,0 +1,149 @@
+package dynamo
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+type eventStatus int
+
+const (
+	eventOpen eventStatus = iota
+	eventClosed
+)
+
+type eventWindow struct {
+	From time.Time
+}
+
+type event struct {
+	ID      string
+	At      time.Time
+	Took    time.Duration
+	Ends    *time.Time `dynamodbav:",omitempty"`
+	Status  eventStatus
+	Tags    map[string]eventStatus
+	History []time.Time
+	Window  eventWindow
+	Addr    net.IP
+}
+
+//unregisterTypes removes registered types such that other tests use the
+//default encoding
+func unregisterTypes(ts ...reflect.Type) {
+	types.Lock()
+	defer types.Unlock()
+	for _, t := range ts {
+		delete(types.m, t)
+	}
+
+	types.has = map[reflect.Type]bool{}
+}
+
+func TestRegisteredTypes(t *testing.T) {
+	RegisterType(EpochSeconds)
+	RegisterType(DurationString)
+	RegisterType(Enum(map[eventStatus]string{eventOpen: "open", eventClosed: "closed"}))
+	RegisterType(Text[net.IP]())
+	defer unregisterTypes(reflect.TypeOf(time.Time{}), reflect.TypeOf(time.Duration(0)),
+		reflect.TypeOf(eventStatus(0)), reflect.TypeOf(net.IP{}))
+
+	at := time.Unix(1500000000, 0).UTC()
+	ends := at.Add(time.Hour)
+	ev := event{
+		ID:      "e1",
+		At:      at,
+		Took:    90 * time.Minute,
+		Ends:    &ends,
+		Status:  eventClosed,
+		Tags:    map[string]eventStatus{"a": eventOpen},
+		History: []time.Time{at, ends},
+		Window:  eventWindow{at},
+		Addr:    net.ParseIP("10.0.0.1"),
+	}
+
+	m, err := marshalItem(ev, nil)
+	ok(t, err)
+	equals(t, "1500000000", aws.StringValue(m["At"].N))
+	equals(t, "1h30m0s", aws.StringValue(m["Took"].S))
+	equals(t, "1500003600", aws.StringValue(m["Ends"].N))
+	equals(t, "closed", aws.StringValue(m["Status"].S))
+	equals(t, "open", aws.StringValue(m["Tags"].M["a"].S))
+	equals(t, "1500003600", aws.StringValue(m["History"].L[1].N))
+	equals(t, "1500000000", aws.StringValue(m["Window"].M["From"].N))
+	equals(t, "10.0.0.1", aws.StringValue(m["Addr"].S))
+
+	var values map[string]*dynamodb.AttributeValue
+	db := &fakeDB{
+		getItem: func(in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: m}, nil
+		},
+		query: func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			values = in.ExpressionAttributeValues
+			return &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{m}}, nil
+		},
+	}
+
+	var got event
+	ok(t, NewGet("tbl", map[string]string{"ID": "e1"}).Execute(db, &got))
+	equals(t, ev, got)
+
+	q := NewQuery("tbl", "ID = :id AND At > :at")
+	q.AddExpressionValue(":id", "e1")
+	q.AddExpressionValue(":at", at)
+	var list []*event
+	_, err = q.Execute(db, &list)
+	ok(t, err)
+	equals(t, "1500000000", aws.StringValue(values[":at"].N))
+	equals(t, []*event{&ev}, list)
+
+	t.Run("invalid attribute", func(t *testing.T) {
+		bad := map[string]*dynamodb.AttributeValue{"At": {S: aws.String("yesterday")}}
+		err := unmarshalItem(bad, &event{}, nil)
+		equals(t, "field 'At': expected a number attribute, got: {\n  S: \"yesterday\"\n}", err.Error())
+	})
+
+	t.Run("unknown enum value", func(t *testing.T) {
+		_, err := marshalValue(eventStatus(7))
+		equals(t, "no name for enum value 7", err.Error())
+	})
+}
+
+func TestRegisteredTypesInInterfaces(t *testing.T) {
+	RegisterType(EpochSeconds)
+	defer unregisterTypes(reflect.TypeOf(time.Time{}))
+
+	at := time.Unix(1500000000, 0).UTC()
+	key, err := MarshalKey(map[string]interface{}{"ID": "e1", "At": at})
+	ok(t, err)
+	equals(t, "1500000000", aws.StringValue(key["At"].N))
+
+	m, err := MarshalItem(struct {
+		ID    string
+		Extra interface{}
+		Attrs map[string]interface{}
+		List  []interface{}
+	}{"e1", &at, map[string]interface{}{"at": at}, []interface{}{"a", at}})
+	ok(t, err)
+	equals(t, "1500000000", aws.StringValue(m["Extra"].N))
+	equals(t, "1500000000", aws.StringValue(m["Attrs"].M["at"].N))
+	equals(t, "1500000000", aws.StringValue(m["List"].L[1].N))
+}
+
+func TestRFC3339Sorts(t *testing.T) {
+	a, err := RFC3339.MarshalAttribute(time.Date(2020, 1, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600)))
+	ok(t, err)
+	b, err := RFC3339.MarshalAttribute(time.Date(2020, 1, 1, 9, 30, 0, 500, time.UTC))
+	ok(t, err)
+	equals(t, "2020-01-01T09:00:00.000000000Z", aws.StringValue(a.S))
+	assert(t, aws.StringValue(a.S) < aws.StringValue(b.S), "expected %s to sort before %s", aws.StringValue(a.S), aws.StringValue(b.S))
+
+	tm, err := RFC3339.UnmarshalAttribute(b)
+	ok(t, err)
+	equals(t, time.Date(2020, 1, 1, 9, 30, 0, 500, time.UTC), tm)
+}
@@ -2,18 +2,21 @@ package dynamo
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
-	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 //Update holds configuration for a delete
 type Update struct {
 	ConditionInput
 	ExpressionHolder
+	TracingInput
+	MetricsInput
 	dynamodb.UpdateItemInput
 	PrimaryKey interface{}
 }
@@ -25,30 +28,46 @@ func NewUpdate(tname string, pk interface{}) *Update {
 	}, PrimaryKey: pk}
 }
 
+//Clone returns a copy of the update that can be configured independently,
+//the primary key and expression values are shared
+func (inp *Update) Clone() *Update {
+	c := *inp
+	c.ExpressionHolder = inp.ExpressionHolder.clone()
+	c.UpdateItemInput = *awsutil.CopyOf(&inp.UpdateItemInput).(*dynamodb.UpdateItemInput)
+	return &c
+}
+
 //Execute will update an item with the background context
-func (inp *Update) Execute(db dynamodbiface.DynamoDBAPI) (err error) {
+func (inp *Update) Execute(db Client) (err error) {
 	return inp.ExecuteWithContext(aws.BackgroundContext(), db)
 }
 
 // ExecuteWithContext updates an item in a DynamoDB table by its primary key pk with exp
-func (inp *Update) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDBAPI) (err error) {
-	ipk, err := dynamodbattribute.MarshalMap(inp.PrimaryKey)
+func (inp *Update) ExecuteWithContext(ctx aws.Context, db Client) (err error) {
+	c := startCall(ctx, inp.Tracer, inp.Metrics, "UpdateItem", inp.TableName, nil)
+	defer func() { c.end(err) }()
+
+	names, values, err := inp.expressionAttributes(inp.UpdateExpression, inp.ConditionExpression)
 	if err != nil {
-		return fmt.Errorf("failed to marshal primary key: %+v", err)
+		return err
 	}
 
-	inp.SetKey(ipk)
-	if len(inp.ExpAttrNames) > 0 {
-		inp.SetExpressionAttributeNames(aws.StringMap(inp.ExpAttrNames))
+	if err = inp.checkCoded(); err != nil {
+		return err
 	}
 
-	if len(inp.ExpAttrValues) > 0 {
-		if inp.ExpressionAttributeValues, err = dynamodbattribute.MarshalMap(inp.ExpAttrValues); err != nil {
-			return fmt.Errorf("failed to marshal expression values: %+v", err)
-		}
+	ipk, err := marshalKey(inp.PrimaryKey)
+	if err != nil {
+		return fmt.Errorf("failed to marshal primary key: %+v", err)
 	}
 
-	if _, err = db.UpdateItemWithContext(ctx, &inp.UpdateItemInput); err != nil {
+	in := inp.UpdateItemInput
+	in.SetKey(ipk)
+	in.ExpressionAttributeNames, in.ExpressionAttributeValues = names, values
+
+	var out *dynamodb.UpdateItemOutput
+	if out, err = db.UpdateItemWithContext(c.ctx, &in); err != nil {
+		c.fail(err)
 		aerr, ok := err.(awserr.Error)
 		if !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
 			return fmt.Errorf("failed to perform request: %+v", err)
@@ -61,5 +80,45 @@ func (inp *Update) ExecuteWithContext(ctx aws.Context, db dynamodbiface.DynamoDB
 		return err
 	}
 
+	c.items(1)
+	c.capacity(out.ConsumedCapacity)
+	return nil
+}
+
+//checkCoded returns an error if the update expression writes an attribute of a
+//coded field of the primary key's struct type. Expression values are not
+//compressed or encrypted, they would be stored as is.
+func (inp *Update) checkCoded() error {
+	fields := codedFields(reflect.TypeOf(inp.PrimaryKey))
+	if len(fields) == 0 || inp.UpdateExpression == nil {
+		return nil
+	}
+
+	coded := map[string]bool{}
+	for _, f := range fields {
+		coded[f.Name] = true
+	}
+
+	clause := ""
+	for _, tok := range scanExpression(aws.StringValue(inp.UpdateExpression)) {
+		if kw := strings.ToUpper(tok.Text); !tok.Nested && (kw == "SET" || kw == "REMOVE" || kw == "ADD" || kw == "DELETE") {
+			clause = kw
+			continue
+		}
+
+		name := tok.Text
+		if tok.Nested || clause == "REMOVE" || strings.HasPrefix(name, ":") {
+			continue
+		}
+
+		if strings.HasPrefix(name, "#") {
+			name = inp.ExpAttrNames[name]
+		}
+
+		if coded[name] {
+			return fmt.Errorf("update expression writes coded attribute '%s', expression values are not compressed or encrypted: write it with a put", name)
+		}
+	}
+
 	return nil
 }
@@ -1,11 +1,17 @@
 package dynamo
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 )
 
 // assert fails the test if the condition is false.
@@ -34,3 +40,84 @@ func equals(tb testing.TB, exp, act interface{}) {
 		tb.FailNow()
 	}
 }
+
+// fakeDB implements the DynamoDB API by calling the configured functions
+type fakeDB struct {
+	dynamodbiface.DynamoDBAPI
+	getItem    func(*dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	putItem    func(*dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	updateItem func(*dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	deleteItem func(*dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	query      func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	scan       func(*dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	batchWrite func(*dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	statement  func(*dynamodb.ExecuteStatementInput) (*dynamodb.ExecuteStatementOutput, error)
+	batchStmt  func(*dynamodb.BatchExecuteStatementInput) (*dynamodb.BatchExecuteStatementOutput, error)
+}
+
+func (db *fakeDB) GetItemWithContext(ctx aws.Context, in *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	return db.getItem(in)
+}
+
+func (db *fakeDB) PutItemWithContext(ctx aws.Context, in *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	return db.putItem(in)
+}
+
+func (db *fakeDB) UpdateItemWithContext(ctx aws.Context, in *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	return db.updateItem(in)
+}
+
+func (db *fakeDB) DeleteItemWithContext(ctx aws.Context, in *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	return db.deleteItem(in)
+}
+
+func (db *fakeDB) QueryWithContext(ctx aws.Context, in *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	return db.query(in)
+}
+
+func (db *fakeDB) ScanWithContext(ctx aws.Context, in *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	return db.scan(in)
+}
+
+func (db *fakeDB) BatchWriteItemWithContext(ctx aws.Context, in *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	return db.batchWrite(in)
+}
+
+func (db *fakeDB) ExecuteStatementWithContext(ctx aws.Context, in *dynamodb.ExecuteStatementInput, opts ...request.Option) (*dynamodb.ExecuteStatementOutput, error) {
+	return db.statement(in)
+}
+
+func (db *fakeDB) BatchExecuteStatementWithContext(ctx aws.Context, in *dynamodb.BatchExecuteStatementInput, opts ...request.Option) (*dynamodb.BatchExecuteStatementOutput, error) {
+	return db.batchStmt(in)
+}
+
+// pages returns a query function that serves the items in pages of n items
+func pages(n int, items ...map[string]*dynamodb.AttributeValue) func(*dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	return func(in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		start := 0
+		if in.ExclusiveStartKey != nil {
+			fmt.Sscan(aws.StringValue(in.ExclusiveStartKey["Pos"].N), &start)
+		}
+
+		end := start + n
+		if end > len(items) {
+			end = len(items)
+		}
+
+		out := &dynamodb.QueryOutput{Items: items[start:end]}
+		out.SetCount(int64(end - start))
+		out.SetScannedCount(int64(end - start))
+		if end < len(items) {
+			out.SetLastEvaluatedKey(map[string]*dynamodb.AttributeValue{"Pos": {N: aws.String(fmt.Sprint(end))}})
+		}
+
+		return out, nil
+	}
+}
+
+// item returns an attribute value map with a single string attribute
+func item(name, val string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{name: {S: aws.String(val)}}
+}
+
+var errExists = errors.New("item already exists")